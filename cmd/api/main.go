@@ -12,10 +12,13 @@ import (
 
 	"github.com/rseigha/goecomapi/internal/config"
 	"github.com/rseigha/goecomapi/internal/database"
+	"github.com/rseigha/goecomapi/internal/domain"
 	"github.com/rseigha/goecomapi/internal/handler"
+	"github.com/rseigha/goecomapi/internal/payment"
 	"github.com/rseigha/goecomapi/internal/repository"
 	"github.com/rseigha/goecomapi/internal/routes"
 	"github.com/rseigha/goecomapi/internal/service"
+	"github.com/rseigha/goecomapi/internal/tax"
 	jwtpkg "github.com/rseigha/goecomapi/pkg/jwt"
 	"go.uber.org/zap"
 )
@@ -33,6 +36,8 @@ func main() {
 	}
 
 
+	domain.DefaultCurrency = cfg.DefaultCurrency
+
 	ctx := context.Background()
 	mongoDB, err := database.NewMongo(ctx, cfg.MongoURI, cfg.MongoDBName, logger)
 	if err != nil {
@@ -44,31 +49,114 @@ func main() {
 	userRepo := repository.NewUserRepository(mongoDB, logger)
 	productRepo := repository.NewProductRepository(mongoDB, logger)
 	orderRepo := repository.NewOrderRepository(mongoDB, logger)
+	cartRepo := repository.NewCartRepository(mongoDB, logger)
+	paymentRepo := repository.NewPaymentRepository(mongoDB, logger)
+	refundRepo := repository.NewRefundRepository(mongoDB, logger)
+	couponRepo := repository.NewCouponRepository(mongoDB, logger)
+	promotionRepo := repository.NewPromotionRepository(mongoDB, logger)
+	shippingMethodRepo := repository.NewShippingMethodRepository(mongoDB, logger)
+	returnRepo := repository.NewReturnRepository(mongoDB, logger)
+	counterRepo := repository.NewCounterRepository(mongoDB, logger)
+	invoiceRepo := repository.NewInvoiceRepository(mongoDB, logger)
+	reservationRepo := repository.NewReservationRepository(mongoDB, logger)
+	idempotencyRepo := repository.NewIdempotencyRepository(mongoDB, time.Duration(cfg.IdempotencyTTLHours)*time.Hour, logger)
 
 	// JWT
 	jwt := jwtpkg.NewJWT(cfg.JWTSecret, time.Duration(cfg.JWTExpiryMinutes)*time.Minute)
 
 	// Services
-	authSvc := service.NewAuthService(userRepo, jwt, logger)
 	userSvc := service.NewUserService(userRepo, logger)
 	productSvc := service.NewProductService(productRepo)
-	orderSvc := service.NewOrderService(orderRepo)
+	couponSvc := service.NewCouponService(couponRepo)
+	promotionSvc := service.NewPromotionService(promotionRepo)
+	shippingSvc := service.NewShippingService(shippingMethodRepo)
+
+	// Payment providers
+	var paymentProviders []service.PaymentProvider
+	if cfg.PaymentFakeSecret != "" {
+		paymentProviders = append(paymentProviders, payment.NewFakeProvider(cfg.PaymentFakeSecret))
+	}
+	refundSvc := service.NewRefundService(refundRepo, orderRepo, paymentRepo, productRepo, mongoDB, paymentProviders, logger)
+
+	// Tax rates
+	taxTable := tax.Flat(cfg.TaxRateBPS)
+	if cfg.TaxTableFile != "" {
+		if taxTable, err = tax.Load(cfg.TaxTableFile); err != nil {
+			logger.Fatal("failed to load tax table", zap.Error(err))
+		}
+	}
+	orderSvc := service.NewOrderService(orderRepo, productRepo, mongoDB, service.OrderCharges{
+		FlatShipping: cfg.ShippingFlatFee,
+		TaxLocation: domain.TaxLocation{
+			Country:    cfg.TaxCountry,
+			State:      cfg.TaxState,
+			PostalCode: cfg.TaxPostalCode,
+		},
+	}, couponSvc, promotionSvc, taxTable, shippingSvc, userSvc, service.NumberSequence{
+		Counters:      counterRepo,
+		Name:          "order_number",
+		Prefix:        cfg.OrderNumberPrefix,
+		DefaultPrefix: service.DefaultOrderNumberPrefix,
+	}, refundSvc, reservationRepo, time.Duration(cfg.ReservationTTLMinutes)*time.Minute)
+	cartSvc := service.NewCartService(cartRepo, productRepo, promotionSvc)
+	authSvc := service.NewAuthService(userRepo, cartSvc, jwt, logger)
+	checkoutSvc := service.NewCheckoutService(cartRepo, orderSvc, mongoDB)
+	paymentSvc := service.NewPaymentService(paymentRepo, orderSvc, paymentProviders, logger)
+	fulfillmentSvc := service.NewFulfillmentService(orderRepo, mongoDB)
+	reservationSvc := service.NewReservationService(reservationRepo, productRepo, orderSvc, mongoDB, logger)
+	returnSvc := service.NewReturnService(returnRepo, orderRepo, productRepo, mongoDB, refundSvc, logger)
+	invoiceSvc := service.NewInvoiceService(invoiceRepo, orderSvc, mongoDB, service.NumberSequence{
+		Counters:      counterRepo,
+		Name:          "invoice_number",
+		Prefix:        cfg.InvoiceNumberPrefix,
+		DefaultPrefix: service.DefaultInvoiceNumberPrefix,
+	}, domain.Seller{
+		Name:    cfg.SellerName,
+		Address: cfg.SellerAddress,
+		TaxID:   cfg.SellerTaxID,
+		Email:   cfg.SellerEmail,
+	})
 
 	// Handlers
 	authHandler := handler.NewAuthHandler(authSvc, logger)
 	userHandler := handler.NewUserHandler(userSvc)
 	productHandler := handler.NewProductHandler(productSvc)
 	orderHandler := handler.NewOrderHandler(orderSvc)
+	cartHandler := handler.NewCartHandler(cartSvc)
+	checkoutHandler := handler.NewCheckoutHandler(checkoutSvc)
+	paymentHandler := handler.NewPaymentHandler(paymentSvc, logger)
+	refundHandler := handler.NewRefundHandler(refundSvc)
+	couponHandler := handler.NewCouponHandler(couponSvc)
+	promotionHandler := handler.NewPromotionHandler(promotionSvc)
+	addressHandler := handler.NewAddressHandler(userSvc)
+	shippingHandler := handler.NewShippingHandler(shippingSvc)
+	shipmentHandler := handler.NewShipmentHandler(fulfillmentSvc)
+	returnHandler := handler.NewReturnHandler(returnSvc)
+	invoiceHandler := handler.NewInvoiceHandler(invoiceSvc, logger)
+	reservationHandler := handler.NewReservationHandler(reservationSvc)
 
 
 	// Router
 	router := routes.NewRouter(&routes.RouterConfig{
-		AuthHandler:    authHandler,
-		UserHandler:    userHandler,
-		ProductHandler: productHandler,
-		OrderHandler:   orderHandler,
-		JWT:            jwt,
-		Logger:         logger,
+		AuthHandler:        authHandler,
+		UserHandler:        userHandler,
+		ProductHandler:     productHandler,
+		OrderHandler:       orderHandler,
+		CartHandler:        cartHandler,
+		CheckoutHandler:    checkoutHandler,
+		PaymentHandler:     paymentHandler,
+		RefundHandler:      refundHandler,
+		CouponHandler:      couponHandler,
+		PromotionHandler:   promotionHandler,
+		AddressHandler:     addressHandler,
+		ShippingHandler:    shippingHandler,
+		ShipmentHandler:    shipmentHandler,
+		ReturnHandler:      returnHandler,
+		InvoiceHandler:     invoiceHandler,
+		ReservationHandler: reservationHandler,
+		Idempotency:        idempotencyRepo,
+		JWT:                jwt,
+		Logger:             logger,
 	})
 
 
@@ -81,6 +169,16 @@ func main() {
 	}
 
 
+	// Release stock held by orders that were never paid
+	sweepCtx, stopSweep := context.WithCancel(ctx)
+	defer stopSweep()
+	if cfg.ReservationTTLMinutes > 0 {
+		if len(paymentProviders) == 0 {
+			logger.Warn("unpaid orders expire but no payment provider is configured; set RESERVATION_TTL_MINUTES=0 to keep them")
+		}
+		go service.SweepReservations(sweepCtx, reservationSvc, time.Duration(cfg.ReservationSweepSeconds)*time.Second, logger)
+	}
+
 	// Run server in goroutine
 	go func() {
 		logger.Info("starting server", zap.Int("port", cfg.Port))
@@ -95,6 +193,7 @@ func main() {
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
 	logger.Info("shutting down server")
+	stopSweep()
 
 	ctxShutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
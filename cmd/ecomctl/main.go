@@ -0,0 +1,152 @@
+// Command ecomctl runs one-off maintenance tasks against the API's database.
+//
+// Usage:
+//
+//	ecomctl migrate-money
+//	ecomctl export-orders [-format csv|ndjson] [-from DATE] [-to DATE] [-status STATUS] [-o FILE]
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/config"
+	"github.com/rseigha/goecomapi/internal/database"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/export"
+	"github.com/rseigha/goecomapi/internal/repository"
+	"go.uber.org/zap"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ecomctl <command>")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  migrate-money   convert legacy float prices and totals to minor units")
+	fmt.Fprintln(os.Stderr, "  export-orders   write orders as CSV or NDJSON, one row per line item")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("failed to load config", zap.Error(err))
+	}
+	domain.DefaultCurrency = cfg.DefaultCurrency
+
+	ctx := context.Background()
+	mongoDB, err := database.NewMongo(ctx, cfg.MongoURI, cfg.MongoDBName, logger)
+	if err != nil {
+		logger.Fatal("failed to connect to MongoDB", zap.Error(err))
+	}
+	defer mongoDB.Close(ctx, logger)
+
+	switch os.Args[1] {
+	case "migrate-money":
+		products, orders, err := repository.MigrateLegacyMoney(ctx, mongoDB, logger)
+		if err != nil {
+			logger.Fatal("money migration failed", zap.Error(err))
+		}
+		fmt.Printf("migrated %d products and %d orders\n", products, orders)
+	case "export-orders":
+		if err := exportOrders(ctx, mongoDB, logger, os.Args[2:]); err != nil {
+			logger.Fatal("order export failed", zap.Error(err))
+		}
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+// exportOrders writes the orders selected by args to stdout or the -o file,
+// oldest first. Dates are YYYY-MM-DD or RFC 3339; -to is exclusive, but a
+// plain -to date covers the whole day.
+func exportOrders(ctx context.Context, db *database.MongoDB, logger *zap.Logger, args []string) (err error) {
+	fs := flag.NewFlagSet("export-orders", flag.ExitOnError)
+	format := fs.String("format", "csv", "output format: csv or ndjson")
+	from := fs.String("from", "", "only orders created at or after this date")
+	to := fs.String("to", "", "only orders created before this date; a plain date includes that day")
+	status := fs.String("status", "", "only orders in this status")
+	out := fs.String("o", "", "output file (default stdout)")
+	fs.Parse(args)
+
+	if !export.Format(*format).Valid() {
+		return fmt.Errorf("%w %q", export.ErrUnknownFormat, *format)
+	}
+	f := domain.OrderFilter{Status: domain.OrderStatus(*status)}
+	if f.CreatedFrom, err = parseDate(*from, false); err != nil {
+		return fmt.Errorf("-from: %w", err)
+	}
+	if f.CreatedTo, err = parseDate(*to, true); err != nil {
+		return fmt.Errorf("-to: %w", err)
+	}
+	if f.CreatedFrom != nil && f.CreatedTo != nil && !f.CreatedFrom.Before(*f.CreatedTo) {
+		return fmt.Errorf("-from must be before -to")
+	}
+
+	var dst io.Writer = os.Stdout
+	if *out != "" {
+		file, cerr := os.Create(*out)
+		if cerr != nil {
+			return cerr
+		}
+		defer func() {
+			// a failed close can mean the export never reached the disk
+			if cerr := file.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		dst = file
+	}
+	buf := bufio.NewWriter(dst)
+	w, err := export.NewWriter(buf, export.Format(*format))
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	err = repository.EachOrder(ctx, repository.NewOrderRepository(db, logger), f, 500, func(o *domain.Order) error {
+		n++
+		return w.Write(o)
+	})
+	if err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d orders\n", n)
+	return nil
+}
+
+func parseDate(v string, upper bool) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: use YYYY-MM-DD or RFC 3339", v)
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
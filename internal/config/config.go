@@ -4,16 +4,36 @@ import (
 	"errors"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Port             int
-	MongoURI         string
-	JWTSecret        string
-	MongoDBName      string
-	JWTExpiryMinutes int
+	Port                int
+	MongoURI            string
+	JWTSecret           string
+	MongoDBName         string
+	JWTExpiryMinutes    int
+	IdempotencyTTLHours int
+	DefaultCurrency     string
+	ShippingFlatFee     int64
+	TaxRateBPS          int64
+	TaxTableFile        string
+	TaxCountry          string
+	TaxState            string
+	TaxPostalCode       string
+	PaymentFakeSecret   string
+	OrderNumberPrefix   string
+	InvoiceNumberPrefix string
+	SellerName          string
+	SellerAddress       []string
+	SellerTaxID         string
+	SellerEmail         string
+	// ReservationTTLMinutes is how long an unpaid order holds its stock;
+	// 0 holds it until the order is paid or canceled.
+	ReservationTTLMinutes   int
+	ReservationSweepSeconds int
 }
 
 func Load() (*Config, error) {
@@ -30,12 +50,74 @@ func Load() (*Config, error) {
 		jwtExpiry = 60 // default to 60 minutes
 	}
 
+	idempotencyTTL, _ := strconv.Atoi(os.Getenv("IDEMPOTENCY_TTL_HOURS"))
+	if idempotencyTTL <= 0 {
+		idempotencyTTL = 24
+	}
+
+	currency := os.Getenv("DEFAULT_CURRENCY")
+	if currency == "" {
+		currency = "USD"
+	}
+
+	shippingFee, _ := strconv.ParseInt(os.Getenv("SHIPPING_FLAT_FEE"), 10, 64)
+	taxRate, _ := strconv.ParseInt(os.Getenv("TAX_RATE_BPS"), 10, 64)
+
+	orderPrefix := os.Getenv("ORDER_NUMBER_PREFIX")
+	if orderPrefix == "" {
+		orderPrefix = "ORD"
+	}
+	invoicePrefix := os.Getenv("INVOICE_NUMBER_PREFIX")
+	if invoicePrefix == "" {
+		invoicePrefix = "INV"
+	}
+
+	// unset means the default; an explicit 0 turns expiry off
+	reservationTTL := 30
+	if v := os.Getenv("RESERVATION_TTL_MINUTES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, errors.New("RESERVATION_TTL_MINUTES must be a whole number of minutes, or 0 for no expiry")
+		}
+		reservationTTL = n
+	}
+	reservationSweep, _ := strconv.Atoi(os.Getenv("RESERVATION_SWEEP_SECONDS"))
+	if reservationSweep <= 0 {
+		reservationSweep = 60
+	}
+
+	// address lines are separated by "|"
+	var sellerAddress []string
+	for _, l := range strings.Split(os.Getenv("SELLER_ADDRESS"), "|") {
+		if l = strings.TrimSpace(l); l != "" {
+			sellerAddress = append(sellerAddress, l)
+		}
+	}
+
 	cfg := &Config{
-		Port:             port,
-		MongoURI:         os.Getenv("MONGODB_URI"),
-		MongoDBName:      os.Getenv("MONGO_DB"),
-		JWTSecret:        os.Getenv("JWT_SECRET"),
-		JWTExpiryMinutes: jwtExpiry,
+		Port:                port,
+		MongoURI:            os.Getenv("MONGODB_URI"),
+		MongoDBName:         os.Getenv("MONGO_DB"),
+		JWTSecret:           os.Getenv("JWT_SECRET"),
+		JWTExpiryMinutes:    jwtExpiry,
+		IdempotencyTTLHours: idempotencyTTL,
+		DefaultCurrency:     currency,
+		ShippingFlatFee:     shippingFee,
+		TaxRateBPS:          taxRate,
+		TaxTableFile:        os.Getenv("TAX_TABLE_FILE"),
+		TaxCountry:          os.Getenv("TAX_COUNTRY"),
+		TaxState:            os.Getenv("TAX_STATE"),
+		TaxPostalCode:       os.Getenv("TAX_POSTAL_CODE"),
+		PaymentFakeSecret:   os.Getenv("PAYMENT_FAKE_SECRET"),
+		OrderNumberPrefix:   orderPrefix,
+		InvoiceNumberPrefix: invoicePrefix,
+		SellerName:          os.Getenv("SELLER_NAME"),
+		SellerAddress:       sellerAddress,
+		SellerTaxID:         os.Getenv("SELLER_TAX_ID"),
+		SellerEmail:         os.Getenv("SELLER_EMAIL"),
+
+		ReservationTTLMinutes:   reservationTTL,
+		ReservationSweepSeconds: reservationSweep,
 	}
 
 	if cfg.MongoURI == "" || cfg.JWTSecret == "" || cfg.MongoDBName == "" {
@@ -16,7 +16,9 @@ type MongoDB struct {
 
 func NewMongo(ctx context.Context, uri, dbName string, logger *zap.Logger) (*MongoDB, error) {
 
-	clientOptions := options.Client().ApplyURI(uri).SetServerSelectionTimeout(10 * time.Second)
+	clientOptions := options.Client().ApplyURI(uri).SetServerSelectionTimeout(10 * time.Second).
+		// domain IDs are hex strings; decode ObjectIDs into them
+		SetBSONOptions(&options.BSONOptions{ObjectIDAsHexString: true})
 	client, err := mongo.Connect(clientOptions)
 	if err != nil {
 		logger.Error("Failed to connect to MongoDB", zap.Error(err))
@@ -57,3 +59,24 @@ func (m *MongoDB) Close(ctx context.Context, logger *zap.Logger) error {
 func (m *MongoDB) Collection(name string) *mongo.Collection {
 	return m.Database.Collection(name)
 }
+
+// WithTransaction runs fn inside a multi-document transaction. The context
+// passed to fn carries the session and must be used for every operation that
+// should take part in the transaction. When ctx already belongs to a
+// transaction, fn joins it instead of starting a new one. Transactions
+// require MongoDB to run as a replica set or sharded cluster.
+func (m *MongoDB) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	sess, err := m.Client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer sess.EndSession(ctx)
+
+	_, err = sess.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
@@ -0,0 +1,25 @@
+package domain
+
+// Address is a postal address. Entries in a user's address book carry an ID;
+// addresses copied onto orders keep it only as a reference, so later edits to
+// the address book do not change past orders.
+type Address struct {
+	ID         string `bson:"id,omitempty" json:"id,omitempty"`
+	Label      string `bson:"label,omitempty" json:"label,omitempty"`
+	Name       string `bson:"name" json:"name"`
+	Line1      string `bson:"line1" json:"line1"`
+	Line2      string `bson:"line2,omitempty" json:"line2,omitempty"`
+	City       string `bson:"city" json:"city"`
+	State      string `bson:"state,omitempty" json:"state,omitempty"`
+	PostalCode string `bson:"postal_code,omitempty" json:"postal_code,omitempty"`
+	// Country is an ISO 3166-1 alpha-2 code.
+	Country string `bson:"country" json:"country"`
+	Phone   string `bson:"phone,omitempty" json:"phone,omitempty"`
+	// IsDefault marks the address book entry used when an order names none.
+	IsDefault bool `bson:"is_default,omitempty" json:"is_default,omitempty"`
+}
+
+// TaxLocation returns the parts of the address that decide tax.
+func (a Address) TaxLocation() TaxLocation {
+	return TaxLocation{Country: a.Country, State: a.State, PostalCode: a.PostalCode}
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+// CartItem is a product the shopper intends to buy. Only ProductID and
+// Quantity are stored; the remaining fields are filled from the catalog
+// every time the cart is read so prices are always current.
+type CartItem struct {
+	ProductID string    `bson:"product_id" json:"product_id"`
+	Quantity  int       `bson:"quantity" json:"quantity"`
+	AddedAt   time.Time `bson:"added_at" json:"added_at"`
+
+	Name      string `bson:"-" json:"name,omitempty"`
+	SKU       string `bson:"-" json:"sku,omitempty"`
+	UnitPrice Money  `bson:"-" json:"unit_price"`
+	LineTotal Money  `bson:"-" json:"line_total"`
+	Available int    `bson:"-" json:"available"`
+	Warning   string `bson:"-" json:"warning,omitempty"`
+}
+
+// CartOwner identifies a cart: signed-in shoppers by user ID, anonymous
+// shoppers by an opaque guest token.
+type CartOwner struct {
+	UserID string
+	Token  string
+}
+
+func (o CartOwner) IsGuest() bool { return o.UserID == "" }
+
+// Cart belongs either to a user (UserID set) or to an anonymous shopper
+// (Token set).
+type Cart struct {
+	ID        string     `bson:"_id,omitempty" json:"id"`
+	UserID    string     `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Token     string     `bson:"token,omitempty" json:"token,omitempty"`
+	Items     []CartItem `bson:"items" json:"items"`
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `bson:"updated_at" json:"updated_at"`
+
+	Subtotal Money    `bson:"-" json:"subtotal"`
+	Warnings []string `bson:"-" json:"warnings,omitempty"`
+	// Discount and Adjustments preview the promotions the cart qualifies
+	// for; coupons are only applied at checkout.
+	Discount    Money        `bson:"-" json:"discount"`
+	Adjustments []Adjustment `bson:"-" json:"adjustments,omitempty"`
+}
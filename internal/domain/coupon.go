@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+type CouponType string
+
+const (
+	CouponPercent CouponType = "percent"
+	CouponFixed   CouponType = "fixed"
+)
+
+// Coupon is a discount code shoppers can apply to an order.
+type Coupon struct {
+	ID   string     `bson:"_id,omitempty" json:"id"`
+	Code string     `bson:"code" json:"code"`
+	Type CouponType `bson:"type" json:"type"`
+	// PercentBPS is the discount of a percent coupon in basis points
+	// (1500 = 15%).
+	PercentBPS int64 `bson:"percent_bps,omitempty" json:"percent_bps,omitempty"`
+	// AmountOff is the discount of a fixed coupon.
+	AmountOff Money `bson:"amount_off" json:"amount_off"`
+	// MinOrder is the smallest subtotal the coupon applies to.
+	MinOrder Money `bson:"min_order" json:"min_order"`
+	// MaxUses and MaxUsesPerUser limit redemptions; zero means unlimited.
+	MaxUses        int `bson:"max_uses" json:"max_uses"`
+	MaxUsesPerUser int `bson:"max_uses_per_user" json:"max_uses_per_user"`
+	Uses           int `bson:"uses" json:"uses"`
+	// StartsAt and EndsAt bound when the coupon can be used.
+	StartsAt *time.Time `bson:"starts_at,omitempty" json:"starts_at,omitempty"`
+	EndsAt   *time.Time `bson:"ends_at,omitempty" json:"ends_at,omitempty"`
+	// ProductIDs restricts the discount to these products; empty means the
+	// whole order.
+	ProductIDs []string  `bson:"product_ids,omitempty" json:"product_ids,omitempty"`
+	Active     bool      `bson:"active" json:"active"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// CouponRedemption records one use of a coupon by an order.
+type CouponRedemption struct {
+	ID        string    `bson:"_id,omitempty" json:"id"`
+	CouponID  string    `bson:"coupon_id" json:"coupon_id"`
+	Code      string    `bson:"code" json:"code"`
+	UserID    string    `bson:"user_id" json:"user_id"`
+	OrderID   string    `bson:"order_id" json:"order_id"`
+	Amount    Money     `bson:"amount" json:"amount"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord stores the outcome of a request made with an
+// Idempotency-Key header so that retries can be answered with the same
+// response.
+type IdempotencyRecord struct {
+	ID          string              `bson:"_id,omitempty" json:"id"`
+	UserID      string              `bson:"user_id" json:"user_id"`
+	Key         string              `bson:"key" json:"key"`
+	RequestHash string              `bson:"request_hash" json:"request_hash"`
+	Completed   bool                `bson:"completed" json:"completed"`
+	StatusCode  int                 `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	Header      map[string][]string `bson:"header,omitempty" json:"header,omitempty"`
+	Body        []byte              `bson:"body,omitempty" json:"-"`
+	CreatedAt   time.Time           `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+// Seller is the business issuing invoices.
+type Seller struct {
+	Name    string   `bson:"name" json:"name"`
+	Address []string `bson:"address,omitempty" json:"address,omitempty"`
+	TaxID   string   `bson:"tax_id,omitempty" json:"tax_id,omitempty"`
+	Email   string   `bson:"email,omitempty" json:"email,omitempty"`
+}
+
+// InvoiceLine is one billed order line. Amount is Quantity × UnitPrice
+// before discounts.
+type InvoiceLine struct {
+	Description string `bson:"description" json:"description"`
+	SKU         string `bson:"sku" json:"sku"`
+	Quantity    int    `bson:"quantity" json:"quantity"`
+	UnitPrice   Money  `bson:"unit_price" json:"unit_price"`
+	Amount      Money  `bson:"amount" json:"amount"`
+	TaxRateBPS  int64  `bson:"tax_rate_bps" json:"tax_rate_bps"`
+	Tax         Money  `bson:"tax" json:"tax"`
+}
+
+// Invoice is the billing document for an order. It is issued once, the
+// first time it is requested, and never changes afterwards; the seller and
+// amounts are copied so later edits do not alter it.
+type Invoice struct {
+	ID          string        `bson:"_id,omitempty" json:"id"`
+	Number      string        `bson:"number" json:"number"`
+	OrderID     string        `bson:"order_id" json:"order_id"`
+	OrderNumber string        `bson:"order_number,omitempty" json:"order_number,omitempty"`
+	UserID      string        `bson:"user_id" json:"user_id"`
+	IssuedAt    time.Time     `bson:"issued_at" json:"issued_at"`
+	Seller      Seller        `bson:"seller" json:"seller"`
+	BillTo      *Address      `bson:"bill_to,omitempty" json:"bill_to,omitempty"`
+	ShipTo      *Address      `bson:"ship_to,omitempty" json:"ship_to,omitempty"`
+	Lines       []InvoiceLine `bson:"lines" json:"lines"`
+	// Adjustments lists the discounts that make up Discount.
+	Adjustments  []Adjustment `bson:"adjustments,omitempty" json:"adjustments,omitempty"`
+	Subtotal     Money        `bson:"subtotal" json:"subtotal"`
+	Discount     Money        `bson:"discount" json:"discount"`
+	Shipping     Money        `bson:"shipping" json:"shipping"`
+	ShippingTax  Money        `bson:"shipping_tax" json:"shipping_tax"`
+	Tax          Money        `bson:"tax" json:"tax"`
+	Total        Money        `bson:"total" json:"total"`
+	TaxInclusive bool         `bson:"tax_inclusive,omitempty" json:"tax_inclusive,omitempty"`
+}
@@ -0,0 +1,193 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrInvalidCurrency  = errors.New("invalid currency code")
+)
+
+// DefaultCurrency is assumed for amounts stored before prices carried a
+// currency. It is set from configuration at startup.
+var DefaultCurrency = "USD"
+
+// currencyExponents holds the number of minor-unit digits for currencies that
+// do not use two. See ISO 4217.
+var currencyExponents = map[string]int{
+	"BHD": 3, "BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "IQD": 3, "ISK": 0,
+	"JOD": 3, "JPY": 0, "KMF": 0, "KRW": 0, "KWD": 3, "LYD": 3, "OMR": 3,
+	"PYG": 0, "RWF": 0, "TND": 3, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+// Money is an amount in the minor units of an ISO 4217 currency, e.g. 1999
+// USD is $19.99. Arithmetic never mixes currencies and always rounds half
+// away from zero to a whole minor unit.
+type Money struct {
+	Amount   int64  `bson:"amount" json:"amount"`
+	Currency string `bson:"currency" json:"currency"`
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// MoneyFromFloat converts an amount in major units (19.99) to Money.
+func MoneyFromFloat(v float64, currency string) Money {
+	scale := math.Pow10(CurrencyExponent(currency))
+	return Money{Amount: int64(math.Round(v * scale)), Currency: currency}
+}
+
+// CurrencyExponent returns the number of minor-unit digits of currency.
+func CurrencyExponent(currency string) int {
+	if e, ok := currencyExponents[currency]; ok {
+		return e
+	}
+	return 2
+}
+
+// ValidCurrency reports whether code looks like an ISO 4217 code.
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Money) IsZero() bool { return m.Amount == 0 }
+
+// Add returns m+o. Both must be in the same currency; a zero value with no
+// currency adopts the other operand's currency.
+func (m Money) Add(o Money) (Money, error) {
+	switch {
+	case m.Currency == "" && m.Amount == 0:
+		return o, nil
+	case o.Currency == "" && o.Amount == 0:
+		return m, nil
+	case m.Currency != o.Currency:
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns m-o under the same rules as Add.
+func (m Money) Sub(o Money) (Money, error) {
+	return m.Add(o.Neg())
+}
+
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Mul multiplies by a whole quantity.
+func (m Money) Mul(qty int) Money {
+	return Money{Amount: m.Amount * int64(qty), Currency: m.Currency}
+}
+
+// MulRatio returns m*num/den rounded half away from zero. Rates such as tax
+// or discount percentages are expressed this way, e.g. 825/10000 for 8.25%.
+func (m Money) MulRatio(num, den int64) Money {
+	return Money{Amount: divRound(m.Amount*num, den), Currency: m.Currency}
+}
+
+// Cmp compares two amounts, returning -1, 0 or +1. Like Add it refuses to
+// compare different currencies, except that a zero value with no currency
+// matches any.
+func (m Money) Cmp(o Money) (int, error) {
+	if m.Currency != o.Currency && !(m.Currency == "" && m.Amount == 0) && !(o.Currency == "" && o.Amount == 0) {
+		return 0, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Major returns the amount in major units. It is meant for display only.
+func (m Money) Major() float64 {
+	return float64(m.Amount) / math.Pow10(CurrencyExponent(m.Currency))
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Decimal formats the amount in major units without the currency code, e.g.
+// "19.99", for documents that state the currency once.
+func (m Money) Decimal() string {
+	exp := CurrencyExponent(m.Currency)
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	if exp == 0 {
+		return fmt.Sprintf("%s%d", sign, amount)
+	}
+	scale := int64(math.Pow10(exp))
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, exp, amount%scale)
+}
+
+// UnmarshalBSONValue accepts both the current {amount, currency} document
+// and the legacy plain number in major units, which is converted using
+// DefaultCurrency. Documents written before the switch to Money therefore
+// keep loading; cmd/ecomctl migrate-money rewrites them in place.
+func (m *Money) UnmarshalBSONValue(typ byte, data []byte) error {
+	rv := bson.RawValue{Type: bson.Type(typ), Value: data}
+	switch rv.Type {
+	case bson.TypeNull:
+		*m = Money{}
+		return nil
+	case bson.TypeDouble:
+		*m = MoneyFromFloat(rv.Double(), DefaultCurrency)
+		return nil
+	case bson.TypeInt32:
+		*m = MoneyFromFloat(float64(rv.Int32()), DefaultCurrency)
+		return nil
+	case bson.TypeInt64:
+		*m = MoneyFromFloat(float64(rv.Int64()), DefaultCurrency)
+		return nil
+	case bson.TypeEmbeddedDocument:
+		type plain Money
+		var p plain
+		if err := rv.Unmarshal(&p); err != nil {
+			return err
+		}
+		*m = Money(p)
+		return nil
+	}
+	return fmt.Errorf("cannot decode %s as money", rv.Type)
+}
+
+// divRound divides rounding half away from zero.
+func divRound(n, d int64) int64 {
+	if d < 0 {
+		n, d = -n, -d
+	}
+	q, r := n/d, n%d
+	if r < 0 {
+		r = -r
+	}
+	if 2*r >= d {
+		if n < 0 {
+			q--
+		} else {
+			q++
+		}
+	}
+	return q
+}
@@ -0,0 +1,230 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestMoneyAdd(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    Money
+		want    Money
+		wantErr bool
+	}{
+		{"same currency", NewMoney(1999, "USD"), NewMoney(1, "USD"), NewMoney(2000, "USD"), false},
+		{"negative", NewMoney(500, "EUR"), NewMoney(-700, "EUR"), NewMoney(-200, "EUR"), false},
+		{"zero adopts right currency", Money{}, NewMoney(250, "GBP"), NewMoney(250, "GBP"), false},
+		{"zero adopts left currency", NewMoney(250, "GBP"), Money{}, NewMoney(250, "GBP"), false},
+		{"mismatch", NewMoney(100, "USD"), NewMoney(100, "EUR"), Money{}, true},
+		{"zero amount with currency still checked", NewMoney(0, "USD"), NewMoney(100, "EUR"), Money{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Add(tt.b)
+			if tt.wantErr {
+				if !errors.Is(err, ErrCurrencyMismatch) {
+					t.Fatalf("Add() error = %v, want ErrCurrencyMismatch", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Add() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneySub(t *testing.T) {
+	got, err := NewMoney(1000, "USD").Sub(NewMoney(1999, "USD"))
+	if err != nil {
+		t.Fatalf("Sub() error = %v", err)
+	}
+	if want := NewMoney(-999, "USD"); got != want {
+		t.Errorf("Sub() = %v, want %v", got, want)
+	}
+	if _, err := NewMoney(1, "USD").Sub(NewMoney(1, "JPY")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub() error = %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestMoneyMul(t *testing.T) {
+	if got, want := NewMoney(1999, "USD").Mul(3), NewMoney(5997, "USD"); got != want {
+		t.Errorf("Mul() = %v, want %v", got, want)
+	}
+	if got, want := NewMoney(1999, "USD").Mul(0), NewMoney(0, "USD"); got != want {
+		t.Errorf("Mul(0) = %v, want %v", got, want)
+	}
+}
+
+func TestMoneyMulRatio(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   int64
+		num, den int64
+		want     int64
+	}{
+		{"exact", 10000, 825, 10000, 825},
+		{"rounds down below half", 1999, 825, 10000, 165}, // 164.9175
+		{"rounds half up", 50, 1, 100, 1},                 // 0.5
+		{"rounds half away from zero", -50, 1, 100, -1},   // -0.5
+		{"negative below half", -1999, 825, 10000, -165},  // -164.9175
+		{"negative denominator", 50, 1, -100, -1},         // -0.5
+		{"just under half", 49, 1, 100, 0},                // 0.49
+		{"thirds", 100, 1, 3, 33},                         // 33.33
+		{"two thirds", 100, 2, 3, 67},                     // 66.67
+		{"whole discount", 1234, 10000, 10000, 1234},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewMoney(tt.amount, "USD").MulRatio(tt.num, tt.den)
+			if got.Amount != tt.want || got.Currency != "USD" {
+				t.Errorf("MulRatio(%d, %d) of %d = %v, want %d USD", tt.num, tt.den, tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyCmp(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    Money
+		want    int
+		wantErr bool
+	}{
+		{"less", NewMoney(1, "USD"), NewMoney(2, "USD"), -1, false},
+		{"equal", NewMoney(2, "USD"), NewMoney(2, "USD"), 0, false},
+		{"greater", NewMoney(3, "USD"), NewMoney(2, "USD"), 1, false},
+		{"zero without currency", Money{}, NewMoney(2, "USD"), -1, false},
+		{"against zero without currency", NewMoney(2, "USD"), Money{}, 1, false},
+		{"mismatch", NewMoney(100, "USD"), NewMoney(1, "EUR"), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Cmp(tt.b)
+			if tt.wantErr {
+				if !errors.Is(err, ErrCurrencyMismatch) {
+					t.Fatalf("Cmp() error = %v, want ErrCurrencyMismatch", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Cmp() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Cmp() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{NewMoney(1999, "USD"), "19.99 USD"},
+		{NewMoney(5, "USD"), "0.05 USD"},
+		{NewMoney(-1999, "USD"), "-19.99 USD"},
+		{NewMoney(-5, "EUR"), "-0.05 EUR"},
+		{NewMoney(1500, "JPY"), "1500 JPY"},
+		{NewMoney(-1500, "JPY"), "-1500 JPY"},
+		{NewMoney(12345, "KWD"), "12.345 KWD"},
+		{NewMoney(0, "USD"), "0.00 USD"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.want {
+			t.Errorf("String() of %d %s = %q, want %q", tt.m.Amount, tt.m.Currency, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyFromFloat(t *testing.T) {
+	tests := []struct {
+		v        float64
+		currency string
+		want     int64
+	}{
+		{19.99, "USD", 1999},
+		{0.1 + 0.2, "USD", 30},
+		{1.005, "USD", 100}, // 1.005 is 1.00499... as a float64
+		{1500, "JPY", 1500},
+		{1.2345, "KWD", 1235},
+		{-2.5, "USD", -250},
+	}
+	for _, tt := range tests {
+		got := MoneyFromFloat(tt.v, tt.currency)
+		if got.Amount != tt.want || got.Currency != tt.currency {
+			t.Errorf("MoneyFromFloat(%v, %s) = %v, want %d %s", tt.v, tt.currency, got, tt.want, tt.currency)
+		}
+	}
+}
+
+func TestMoneyUnmarshalBSONValue(t *testing.T) {
+	old := DefaultCurrency
+	DefaultCurrency = "EUR"
+	defer func() { DefaultCurrency = old }()
+
+	type doc struct {
+		Price Money `bson:"price"`
+	}
+	tests := []struct {
+		name    string
+		in      bson.D
+		want    Money
+		wantErr bool
+	}{
+		{"current document", bson.D{{Key: "price", Value: bson.D{{Key: "amount", Value: int64(1999)}, {Key: "currency", Value: "USD"}}}}, NewMoney(1999, "USD"), false},
+		{"legacy double", bson.D{{Key: "price", Value: 19.99}}, NewMoney(1999, "EUR"), false},
+		{"legacy double rounding", bson.D{{Key: "price", Value: 0.1 + 0.2}}, NewMoney(30, "EUR"), false},
+		{"legacy int32", bson.D{{Key: "price", Value: int32(20)}}, NewMoney(2000, "EUR"), false},
+		{"legacy int64", bson.D{{Key: "price", Value: int64(20)}}, NewMoney(2000, "EUR"), false},
+		{"null", bson.D{{Key: "price", Value: nil}}, Money{}, false},
+		{"string", bson.D{{Key: "price", Value: "19.99"}}, Money{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := bson.Marshal(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got doc
+			err = bson.Unmarshal(raw, &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Unmarshal() = %v, want an error", got.Price)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got.Price != tt.want {
+				t.Errorf("Unmarshal() = %v, want %v", got.Price, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyBSONRoundTrip(t *testing.T) {
+	type doc struct {
+		Price Money `bson:"price"`
+	}
+	in := doc{Price: NewMoney(-12345, "KWD")}
+	raw, err := bson.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out doc
+	if err := bson.Unmarshal(raw, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out != in {
+		t.Errorf("round trip = %v, want %v", out.Price, in.Price)
+	}
+}
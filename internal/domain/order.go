@@ -5,24 +5,122 @@ import "time"
 type OrderStatus string
 
 const (
-	OrderPending   OrderStatus = "pending"
-	OrderCompleted OrderStatus = "completed"
-	OrderCanceled  OrderStatus = "canceled"
+	OrderPending       OrderStatus = "pending"
+	OrderPaymentFailed OrderStatus = "payment_failed"
+	OrderPaid          OrderStatus = "paid"
+	OrderProcessing    OrderStatus = "processing"
+	OrderShipped       OrderStatus = "shipped"
+	OrderDelivered     OrderStatus = "delivered"
+	OrderCanceled      OrderStatus = "canceled"
+	OrderRefunded      OrderStatus = "refunded"
+
+	OrderPartiallyRefunded OrderStatus = "partially_refunded"
+	OrderPartiallyShipped  OrderStatus = "partially_shipped"
 )
 
 type OrderItem struct {
-	ProductID string  `bson:"product_id" json:"product_id"`
-	Name      string  `bson:"name" json:"name"`
-	Price     float64 `bson:"price" json:"price"`
-	Quantity  int     `bson:"quantity" json:"quantity"`
+	ProductID string `bson:"product_id" json:"product_id"`
+	Name      string `bson:"name" json:"name"`
+	SKU       string `bson:"sku" json:"sku"`
+	Price     Money  `bson:"price" json:"price"`
+	Quantity  int    `bson:"quantity" json:"quantity"`
+
+	TaxClass    string `bson:"tax_class,omitempty" json:"tax_class,omitempty"`
+	WeightGrams int    `bson:"weight_grams,omitempty" json:"weight_grams,omitempty"`
+	// Tax is the tax on the whole line at TaxRateBPS, after discounts.
+	Tax        Money `bson:"tax" json:"tax"`
+	TaxRateBPS int64 `bson:"tax_rate_bps" json:"tax_rate_bps"`
+
+	// RefundedQuantity counts the units already covered by refunds.
+	RefundedQuantity int `bson:"refunded_quantity,omitempty" json:"refunded_quantity,omitempty"`
+	// ShippedQuantity counts the units already sent in shipments.
+	ShippedQuantity int `bson:"shipped_quantity,omitempty" json:"shipped_quantity,omitempty"`
+	// ReturnedQuantity counts the units on returns that were not rejected
+	// or canceled.
+	ReturnedQuantity int `bson:"returned_quantity,omitempty" json:"returned_quantity,omitempty"`
+}
+
+// StatusChange is one entry in an order's status history.
+type StatusChange struct {
+	From    OrderStatus `bson:"from,omitempty" json:"from,omitempty"`
+	To      OrderStatus `bson:"to" json:"to"`
+	At      time.Time   `bson:"at" json:"at"`
+	ActorID string      `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	Reason  string      `bson:"reason,omitempty" json:"reason,omitempty"`
 }
 
 type Order struct {
 	ID        string      `bson:"_id,omitempty" json:"id"`
+	Number    string      `bson:"number,omitempty" json:"number,omitempty"`
 	UserID    string      `bson:"user_id" json:"user_id"`
 	Items     []OrderItem `bson:"items" json:"items"`
-	Total     float64     `bson:"total" json:"total"`
+	Subtotal  Money       `bson:"subtotal" json:"subtotal"`
+	Discount  Money       `bson:"discount" json:"discount"`
+	Shipping  Money       `bson:"shipping" json:"shipping"`
+	Tax       Money       `bson:"tax" json:"tax"`
+	Total     Money       `bson:"total" json:"total"`
+	Refunded  Money       `bson:"refunded" json:"refunded"`
 	Status    OrderStatus `bson:"status" json:"status"`
 	CreatedAt time.Time   `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time   `bson:"updated_at" json:"updated_at"`
+
+	// ShippingAddress and BillingAddress are snapshots taken when the order
+	// was placed.
+	ShippingAddress *Address `bson:"shipping_address,omitempty" json:"shipping_address,omitempty"`
+	BillingAddress  *Address `bson:"billing_address,omitempty" json:"billing_address,omitempty"`
+	// ShippingMethod is the code of the chosen shipping method; empty means
+	// the default flat fee was charged.
+	ShippingMethod string `bson:"shipping_method,omitempty" json:"shipping_method,omitempty"`
+
+	// ShippingTax is the part of Tax charged on shipping. When
+	// TaxInclusive is set, prices already contained the tax and Tax only
+	// reports it; otherwise it was added to Total.
+	ShippingTax  Money       `bson:"shipping_tax" json:"shipping_tax"`
+	TaxInclusive bool        `bson:"tax_inclusive,omitempty" json:"tax_inclusive,omitempty"`
+	TaxLocation  TaxLocation `bson:"tax_location" json:"tax_location"`
+
+	// CouponCode is the coupon whose discount was applied, if any.
+	CouponCode string `bson:"coupon_code,omitempty" json:"coupon_code,omitempty"`
+	// Adjustments explain how Discount was made up, one entry per
+	// promotion or coupon applied.
+	Adjustments []Adjustment `bson:"adjustments,omitempty" json:"adjustments,omitempty"`
+
+	// ReservedUntil is when the order's stock is released and the order
+	// canceled if it is still unpaid.
+	ReservedUntil *time.Time `bson:"reserved_until,omitempty" json:"reserved_until,omitempty"`
+
+	CanceledBy   string     `bson:"canceled_by,omitempty" json:"canceled_by,omitempty"`
+	CancelReason string     `bson:"cancel_reason,omitempty" json:"cancel_reason,omitempty"`
+	CanceledAt   *time.Time `bson:"canceled_at,omitempty" json:"canceled_at,omitempty"`
+
+	// Shipments lists the parcels sent so far, oldest first.
+	Shipments []Shipment `bson:"shipments,omitempty" json:"shipments,omitempty"`
+
+	// History is append-only; every status change pushes a new entry.
+	History []StatusChange `bson:"history" json:"history"`
+}
+
+// OrderFilter narrows an order search. Zero values mean "no constraint".
+type OrderFilter struct {
+	Status      OrderStatus
+	UserID      string
+	ProductID   string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	// MinTotal and MaxTotal are in minor units of Currency.
+	Currency string
+	MinTotal *int64
+	MaxTotal *int64
+	// Sort is a field name, optionally prefixed with "-" for descending
+	// order. Defaults to "-created_at".
+	Sort  string
+	Limit int
+	Page  int
+}
+
+// OrderPage is a cursor-paginated slice of orders. NextCursor is empty on the
+// last page.
+type OrderPage struct {
+	Items      []*Order `json:"items"`
+	NextCursor string   `json:"next_cursor,omitempty"`
 }
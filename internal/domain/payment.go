@@ -0,0 +1,49 @@
+package domain
+
+import "time"
+
+type PaymentStatus string
+
+const (
+	PaymentPending    PaymentStatus = "pending"
+	PaymentAuthorized PaymentStatus = "authorized"
+	PaymentCaptured   PaymentStatus = "captured"
+	PaymentFailed     PaymentStatus = "failed"
+	PaymentRefunded   PaymentStatus = "refunded"
+)
+
+// Payment records one attempt to collect an order's total through a payment
+// provider. ProviderRef is the provider's own ID for the payment intent.
+type Payment struct {
+	ID            string        `bson:"_id,omitempty" json:"id"`
+	OrderID       string        `bson:"order_id" json:"order_id"`
+	UserID        string        `bson:"user_id" json:"user_id"`
+	Provider      string        `bson:"provider" json:"provider"`
+	ProviderRef   string        `bson:"provider_ref" json:"provider_ref"`
+	Amount        Money         `bson:"amount" json:"amount"`
+	Refunded      Money         `bson:"refunded" json:"refunded"`
+	Status        PaymentStatus `bson:"status" json:"status"`
+	FailureReason string        `bson:"failure_reason,omitempty" json:"failure_reason,omitempty"`
+	CreatedAt     time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time     `bson:"updated_at" json:"updated_at"`
+
+	// ClientSecret lets the client confirm the payment with the provider.
+	// It is returned once and never stored.
+	ClientSecret string `bson:"-" json:"client_secret,omitempty"`
+}
+
+type PaymentEventType string
+
+const (
+	PaymentEventAuthorized PaymentEventType = "payment.authorized"
+	PaymentEventFailed     PaymentEventType = "payment.failed"
+)
+
+// PaymentEvent is a provider webhook notification whose signature has been
+// verified.
+type PaymentEvent struct {
+	ID            string           `json:"id"`
+	Type          PaymentEventType `json:"type"`
+	ProviderRef   string           `json:"provider_ref"`
+	FailureReason string           `json:"failure_reason,omitempty"`
+}
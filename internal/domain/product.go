@@ -2,13 +2,17 @@ package domain
 
 import "time"
 
+// Product is a catalog item. Stock is the quantity still available to sell;
+// units held for unpaid orders are not part of it (see Reservation).
 type Product struct {
 	ID          string    `bson:"_id,omitempty" json:"id"`
 	Name        string    `bson:"name" json:"name"`
 	Description string    `bson:"description" json:"description"`
 	SKU         string    `bson:"sku" json:"sku"`
-	Price       float64   `bson:"price" json:"price"`
+	Price       Money     `bson:"price" json:"price"`
 	Stock       int       `bson:"stock" json:"stock"`
+	TaxClass    string    `bson:"tax_class,omitempty" json:"tax_class,omitempty"`
+	WeightGrams int       `bson:"weight_grams,omitempty" json:"weight_grams,omitempty"`
 	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
 	UpdatedAt   time.Time `bson:"updated_at" json:"updated_at"`
 }
@@ -0,0 +1,75 @@
+package domain
+
+import "time"
+
+type PromotionType string
+
+const (
+	// PromoBuyXGetY discounts every GetQuantity units after BuyQuantity
+	// units of the eligible products, e.g. buy 2 get 1 free.
+	PromoBuyXGetY PromotionType = "buy_x_get_y"
+	// PromoSpendThreshold takes money off once the eligible spend reaches
+	// one of its tiers, e.g. spend 100 save 15.
+	PromoSpendThreshold PromotionType = "spend_threshold"
+	// PromoBundle sells one unit of each of its products for BundlePrice.
+	PromoBundle PromotionType = "bundle"
+	// PromoFreeItem gives FreeProductID away with every MinQuantity units
+	// of the trigger products. The free product must be in the cart.
+	PromoFreeItem PromotionType = "free_item"
+)
+
+// PromotionTier is one step of a spend threshold promotion. Either
+// AmountOff or PercentBPS is set.
+type PromotionTier struct {
+	Threshold  Money `bson:"threshold" json:"threshold"`
+	AmountOff  Money `bson:"amount_off" json:"amount_off"`
+	PercentBPS int64 `bson:"percent_bps,omitempty" json:"percent_bps,omitempty"`
+}
+
+// Promotion is a rule applied automatically to carts and orders. Which
+// fields are used depends on Type.
+type Promotion struct {
+	ID     string        `bson:"_id,omitempty" json:"id"`
+	Name   string        `bson:"name" json:"name"`
+	Type   PromotionType `bson:"type" json:"type"`
+	Active bool          `bson:"active" json:"active"`
+	// Priority orders evaluation; higher runs first.
+	Priority int        `bson:"priority" json:"priority"`
+	StartsAt *time.Time `bson:"starts_at,omitempty" json:"starts_at,omitempty"`
+	EndsAt   *time.Time `bson:"ends_at,omitempty" json:"ends_at,omitempty"`
+	// ProductIDs are the eligible products (buy_x_get_y, spend_threshold),
+	// the bundle's products, or the trigger products of free_item. Empty
+	// means every product where that makes sense.
+	ProductIDs []string `bson:"product_ids,omitempty" json:"product_ids,omitempty"`
+
+	BuyQuantity int `bson:"buy_quantity,omitempty" json:"buy_quantity,omitempty"`
+	GetQuantity int `bson:"get_quantity,omitempty" json:"get_quantity,omitempty"`
+	// PercentBPS is the discount on the "get" units in basis points;
+	// 10000 makes them free.
+	PercentBPS int64 `bson:"percent_bps,omitempty" json:"percent_bps,omitempty"`
+
+	Tiers []PromotionTier `bson:"tiers,omitempty" json:"tiers,omitempty"`
+
+	BundlePrice Money `bson:"bundle_price" json:"bundle_price"`
+
+	MinQuantity   int    `bson:"min_quantity,omitempty" json:"min_quantity,omitempty"`
+	FreeProductID string `bson:"free_product_id,omitempty" json:"free_product_id,omitempty"`
+	FreeQuantity  int    `bson:"free_quantity,omitempty" json:"free_quantity,omitempty"`
+
+	// MaxApplications caps how often the rule applies to one cart; zero
+	// means no cap.
+	MaxApplications int       `bson:"max_applications,omitempty" json:"max_applications,omitempty"`
+	CreatedAt       time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Adjustment explains one discount applied to a cart or order, either by a
+// promotion or by a coupon.
+type Adjustment struct {
+	PromotionID string `bson:"promotion_id,omitempty" json:"promotion_id,omitempty"`
+	CouponCode  string `bson:"coupon_code,omitempty" json:"coupon_code,omitempty"`
+	Name        string `bson:"name" json:"name"`
+	Description string `bson:"description" json:"description"`
+	// Amount is the money taken off, as a positive value.
+	Amount Money `bson:"amount" json:"amount"`
+}
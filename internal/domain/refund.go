@@ -0,0 +1,38 @@
+package domain
+
+import "time"
+
+type RefundStatus string
+
+const (
+	RefundPending   RefundStatus = "pending"
+	RefundSucceeded RefundStatus = "succeeded"
+	RefundFailed    RefundStatus = "failed"
+)
+
+// RefundLine is the part of one order line covered by a refund.
+type RefundLine struct {
+	ProductID string `bson:"product_id" json:"product_id"`
+	SKU       string `bson:"sku" json:"sku"`
+	Quantity  int    `bson:"quantity" json:"quantity"`
+	Amount    Money  `bson:"amount" json:"amount"`
+}
+
+// Refund records money given back against a captured payment. Refunds are
+// never modified after they settle, so they double as the accounting record.
+type Refund struct {
+	ID            string       `bson:"_id,omitempty" json:"id"`
+	OrderID       string       `bson:"order_id" json:"order_id"`
+	PaymentID     string       `bson:"payment_id" json:"payment_id"`
+	Provider      string       `bson:"provider" json:"provider"`
+	ProviderRef   string       `bson:"provider_ref,omitempty" json:"provider_ref,omitempty"`
+	Amount        Money        `bson:"amount" json:"amount"`
+	Lines         []RefundLine `bson:"lines" json:"lines"`
+	Restock       bool         `bson:"restock" json:"restock"`
+	Reason        string       `bson:"reason,omitempty" json:"reason,omitempty"`
+	ActorID       string       `bson:"actor_id" json:"actor_id"`
+	Status        RefundStatus `bson:"status" json:"status"`
+	FailureReason string       `bson:"failure_reason,omitempty" json:"failure_reason,omitempty"`
+	CreatedAt     time.Time    `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time    `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+type ReservationStatus string
+
+const (
+	// ReservationActive holds stock for an order awaiting payment.
+	ReservationActive ReservationStatus = "active"
+	// ReservationCommitted means the order was paid and the units sold.
+	ReservationCommitted ReservationStatus = "committed"
+	// ReservationReleased means the units went back on sale, either because
+	// the order was canceled or because payment did not arrive in time.
+	ReservationReleased ReservationStatus = "released"
+)
+
+// ReservationLine is the number of units of one product held.
+type ReservationLine struct {
+	ProductID string `bson:"product_id" json:"product_id"`
+	SKU       string `bson:"sku" json:"sku"`
+	Quantity  int    `bson:"quantity" json:"quantity"`
+}
+
+// Reservation records the stock taken off sale for an unpaid order.
+//
+// Product.Stock means unreserved, available stock: placing an order moves
+// its units out of Stock and into an active reservation, so the units an
+// active reservation lists are exactly the reserved quantity. Paying
+// commits the reservation and the units stay out of Stock as sold.
+// Releasing it, by canceling the order or letting it expire, is what puts
+// the units back into Stock.
+type Reservation struct {
+	ID      string            `bson:"_id,omitempty" json:"id"`
+	OrderID string            `bson:"order_id" json:"order_id"`
+	UserID  string            `bson:"user_id" json:"user_id"`
+	Lines   []ReservationLine `bson:"lines" json:"lines"`
+	Status  ReservationStatus `bson:"status" json:"status"`
+	// ExpiresAt is when an unpaid order loses its stock; nil means never.
+	ExpiresAt     *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	ReleaseReason string     `bson:"release_reason,omitempty" json:"release_reason,omitempty"`
+	CreatedAt     time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+// StockLevel splits a product's stock into what can still be sold
+// (Product.Stock) and what is held by active reservations; OnHand is both
+// together.
+type StockLevel struct {
+	ProductID string `bson:"_id" json:"product_id"`
+	SKU       string `bson:"sku" json:"sku"`
+	Available int    `bson:"-" json:"available"`
+	Reserved  int    `bson:"reserved" json:"reserved"`
+	OnHand    int    `bson:"-" json:"on_hand"`
+	// Orders counts the unpaid orders holding the reserved units.
+	Orders int `bson:"orders" json:"orders"`
+}
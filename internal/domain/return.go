@@ -0,0 +1,55 @@
+package domain
+
+import "time"
+
+type ReturnStatus string
+
+const (
+	ReturnRequested ReturnStatus = "requested"
+	ReturnApproved  ReturnStatus = "approved"
+	ReturnReceived  ReturnStatus = "received"
+	// ReturnAccepted means the goods passed inspection and were restocked.
+	ReturnAccepted  ReturnStatus = "accepted"
+	ReturnRefunded  ReturnStatus = "refunded"
+	ReturnExchanged ReturnStatus = "exchanged"
+	ReturnRejected  ReturnStatus = "rejected"
+	ReturnCanceled  ReturnStatus = "canceled"
+)
+
+// ReturnLine is the number of units of one order line being returned.
+type ReturnLine struct {
+	ProductID string `bson:"product_id" json:"product_id"`
+	SKU       string `bson:"sku" json:"sku"`
+	Name      string `bson:"name" json:"name"`
+	Quantity  int    `bson:"quantity" json:"quantity"`
+}
+
+// ReturnStatusChange is one entry in a return's status history.
+type ReturnStatusChange struct {
+	From    ReturnStatus `bson:"from,omitempty" json:"from,omitempty"`
+	To      ReturnStatus `bson:"to" json:"to"`
+	At      time.Time    `bson:"at" json:"at"`
+	ActorID string       `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	Note    string       `bson:"note,omitempty" json:"note,omitempty"`
+}
+
+// Return is a customer's request to send back items from an order (an RMA).
+type Return struct {
+	ID        string       `bson:"_id,omitempty" json:"id"`
+	OrderID   string       `bson:"order_id" json:"order_id"`
+	UserID    string       `bson:"user_id" json:"user_id"`
+	Lines     []ReturnLine `bson:"lines" json:"lines"`
+	Reason    string       `bson:"reason" json:"reason"`
+	PhotoURLs []string     `bson:"photo_urls,omitempty" json:"photo_urls,omitempty"`
+	Status    ReturnStatus `bson:"status" json:"status"`
+	CreatedAt time.Time    `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time    `bson:"updated_at" json:"updated_at"`
+
+	// Restocked is set once the returned units were put back into stock.
+	Restocked bool `bson:"restocked,omitempty" json:"restocked,omitempty"`
+	// RefundID links the refund issued for a refunded return.
+	RefundID string `bson:"refund_id,omitempty" json:"refund_id,omitempty"`
+
+	// History is append-only; every status change pushes a new entry.
+	History []ReturnStatusChange `bson:"history" json:"history"`
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+// ShipmentLine is the number of units of one order line sent in a shipment.
+type ShipmentLine struct {
+	ProductID string `bson:"product_id" json:"product_id"`
+	SKU       string `bson:"sku" json:"sku"`
+	Name      string `bson:"name" json:"name"`
+	Quantity  int    `bson:"quantity" json:"quantity"`
+}
+
+// Shipment is one parcel sent against an order. An order may ship in several
+// shipments; they are stored on the order so customers see the tracking
+// details with it.
+type Shipment struct {
+	ID             string         `bson:"id" json:"id"`
+	Carrier        string         `bson:"carrier" json:"carrier"`
+	TrackingNumber string         `bson:"tracking_number,omitempty" json:"tracking_number,omitempty"`
+	TrackingURL    string         `bson:"tracking_url,omitempty" json:"tracking_url,omitempty"`
+	Lines          []ShipmentLine `bson:"lines" json:"lines"`
+	ShippedAt      time.Time      `bson:"shipped_at" json:"shipped_at"`
+	CreatedBy      string         `bson:"created_by" json:"created_by"`
+	CreatedAt      time.Time      `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time      `bson:"updated_at" json:"updated_at"`
+}
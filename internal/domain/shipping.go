@@ -0,0 +1,38 @@
+package domain
+
+import "time"
+
+type ShippingRateType string
+
+const (
+	// ShippingFlat charges Cost on every order.
+	ShippingFlat ShippingRateType = "flat"
+	// ShippingWeight charges by the order's total weight using WeightTiers.
+	ShippingWeight ShippingRateType = "weight"
+	// ShippingFreeOver charges Cost unless the order reaches FreeOver.
+	ShippingFreeOver ShippingRateType = "free_over"
+)
+
+// WeightTier prices orders weighing up to MaxGrams. A zero MaxGrams has no
+// upper bound.
+type WeightTier struct {
+	MaxGrams int   `bson:"max_grams" json:"max_grams"`
+	Cost     Money `bson:"cost" json:"cost"`
+}
+
+// ShippingMethod is an entry in the shipping catalogue customers choose from
+// at order time.
+type ShippingMethod struct {
+	ID          string           `bson:"_id,omitempty" json:"id"`
+	Code        string           `bson:"code" json:"code"`
+	Name        string           `bson:"name" json:"name"`
+	Type        ShippingRateType `bson:"type" json:"type"`
+	Cost        Money            `bson:"cost" json:"cost"`
+	FreeOver    Money            `bson:"free_over" json:"free_over"`
+	WeightTiers []WeightTier     `bson:"weight_tiers,omitempty" json:"weight_tiers,omitempty"`
+	// Countries limits where the method ships; empty means everywhere.
+	Countries []string  `bson:"countries,omitempty" json:"countries,omitempty"`
+	Active    bool      `bson:"active" json:"active"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,9 @@
+package domain
+
+// TaxLocation is where an order is taxed. Country and State are ISO 3166
+// codes, e.g. "US" and "CA".
+type TaxLocation struct {
+	Country    string `bson:"country,omitempty" json:"country,omitempty"`
+	State      string `bson:"state,omitempty" json:"state,omitempty"`
+	PostalCode string `bson:"postal_code,omitempty" json:"postal_code,omitempty"`
+}
@@ -17,4 +17,9 @@ type User struct {
 	Role         Role      `bson:"role" json:"role"`
 	CreatedAt    time.Time `bson:"created_at" json:"created_at"`
 	UpdatedAt    time.Time `bson:"updated_at" json:"updated_at"`
+
+	// Addresses is the user's address book. It is managed through its own
+	// endpoints and left out of the document when empty, so profile updates
+	// do not clear it.
+	Addresses []Address `bson:"addresses,omitempty" json:"addresses,omitempty"`
 }
@@ -0,0 +1,202 @@
+// Package export writes orders in flat formats for accounting, one row per
+// order line. Order-level amounts are repeated on every row of the order so
+// each row can be read on its own.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+)
+
+type Format string
+
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+)
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// Valid reports whether f is a format NewWriter can produce.
+func (f Format) Valid() bool {
+	return f == CSV || f == NDJSON
+}
+
+// ContentType is the MIME type of f.
+func (f Format) ContentType() string {
+	if f == NDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Row is one order line. Amounts are decimal strings in Currency, without
+// the currency code.
+type Row struct {
+	OrderID          string `json:"order_id"`
+	OrderNumber      string `json:"order_number"`
+	CreatedAt        string `json:"created_at"`
+	Status           string `json:"status"`
+	UserID           string `json:"user_id"`
+	Currency         string `json:"currency"`
+	Line             int    `json:"line"`
+	ProductID        string `json:"product_id"`
+	SKU              string `json:"sku"`
+	Name             string `json:"name"`
+	Quantity         int    `json:"quantity"`
+	UnitPrice        string `json:"unit_price"`
+	LineAmount       string `json:"line_amount"`
+	LineTax          string `json:"line_tax"`
+	TaxRateBPS       int64  `json:"tax_rate_bps"`
+	ShippedQuantity  int    `json:"shipped_quantity"`
+	RefundedQuantity int    `json:"refunded_quantity"`
+	ReturnedQuantity int    `json:"returned_quantity"`
+	OrderSubtotal    string `json:"order_subtotal"`
+	OrderDiscount    string `json:"order_discount"`
+	OrderShipping    string `json:"order_shipping"`
+	OrderTax         string `json:"order_tax"`
+	OrderTotal       string `json:"order_total"`
+	OrderRefunded    string `json:"order_refunded"`
+	TaxInclusive     bool   `json:"tax_inclusive"`
+	CouponCode       string `json:"coupon_code"`
+	ShippingMethod   string `json:"shipping_method"`
+	ShippingCountry  string `json:"shipping_country"`
+}
+
+// Header lists the CSV columns, in the order of Row's fields.
+var Header = []string{
+	"order_id", "order_number", "created_at", "status", "user_id", "currency",
+	"line", "product_id", "sku", "name", "quantity", "unit_price",
+	"line_amount", "line_tax", "tax_rate_bps", "shipped_quantity",
+	"refunded_quantity", "returned_quantity", "order_subtotal",
+	"order_discount", "order_shipping", "order_tax", "order_total",
+	"order_refunded", "tax_inclusive", "coupon_code", "shipping_method",
+	"shipping_country",
+}
+
+func (r *Row) record() []string {
+	return []string{
+		r.OrderID, r.OrderNumber, r.CreatedAt, r.Status, r.UserID, r.Currency,
+		strconv.Itoa(r.Line), r.ProductID, r.SKU, r.Name, strconv.Itoa(r.Quantity), r.UnitPrice,
+		r.LineAmount, r.LineTax, strconv.FormatInt(r.TaxRateBPS, 10), strconv.Itoa(r.ShippedQuantity),
+		strconv.Itoa(r.RefundedQuantity), strconv.Itoa(r.ReturnedQuantity), r.OrderSubtotal,
+		r.OrderDiscount, r.OrderShipping, r.OrderTax, r.OrderTotal,
+		r.OrderRefunded, strconv.FormatBool(r.TaxInclusive), r.CouponCode, r.ShippingMethod,
+		r.ShippingCountry,
+	}
+}
+
+// Rows flattens o into one row per item, numbered from 1.
+func Rows(o *domain.Order) []Row {
+	rows := make([]Row, 0, len(o.Items))
+	country := ""
+	if o.ShippingAddress != nil {
+		country = o.ShippingAddress.Country
+	}
+	for i, it := range o.Items {
+		rows = append(rows, Row{
+			OrderID:          o.ID,
+			OrderNumber:      o.Number,
+			CreatedAt:        o.CreatedAt.UTC().Format(time.RFC3339),
+			Status:           string(o.Status),
+			UserID:           o.UserID,
+			Currency:         o.Total.Currency,
+			Line:             i + 1,
+			ProductID:        it.ProductID,
+			SKU:              it.SKU,
+			Name:             it.Name,
+			Quantity:         it.Quantity,
+			UnitPrice:        it.Price.Decimal(),
+			LineAmount:       it.Price.Mul(it.Quantity).Decimal(),
+			LineTax:          it.Tax.Decimal(),
+			TaxRateBPS:       it.TaxRateBPS,
+			ShippedQuantity:  it.ShippedQuantity,
+			RefundedQuantity: it.RefundedQuantity,
+			ReturnedQuantity: it.ReturnedQuantity,
+			OrderSubtotal:    o.Subtotal.Decimal(),
+			OrderDiscount:    o.Discount.Decimal(),
+			OrderShipping:    o.Shipping.Decimal(),
+			OrderTax:         o.Tax.Decimal(),
+			OrderTotal:       o.Total.Decimal(),
+			OrderRefunded:    o.Refunded.Decimal(),
+			TaxInclusive:     o.TaxInclusive,
+			CouponCode:       o.CouponCode,
+			ShippingMethod:   o.ShippingMethod,
+			ShippingCountry:  country,
+		})
+	}
+	return rows
+}
+
+// Writer writes orders as rows. Output may be buffered; Flush writes it
+// out and must be called when done.
+type Writer interface {
+	Write(o *domain.Order) error
+	Flush() error
+}
+
+// NewWriter returns a Writer producing format on w. CSV output starts with
+// a header row.
+func NewWriter(w io.Writer, format Format) (Writer, error) {
+	switch format {
+	case CSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case NDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+type csvWriter struct {
+	w          *csv.Writer
+	headerDone bool
+}
+
+func (c *csvWriter) Write(o *domain.Order) error {
+	if !c.headerDone {
+		if err := c.w.Write(Header); err != nil {
+			return err
+		}
+		c.headerDone = true
+	}
+	for _, r := range Rows(o) {
+		if err := c.w.Write(r.record()); err != nil {
+			return err
+		}
+	}
+	return c.w.Error()
+}
+
+func (c *csvWriter) Flush() error {
+	if !c.headerDone {
+		// an empty export still gets its header
+		if err := c.w.Write(Header); err != nil {
+			return err
+		}
+		c.headerDone = true
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(o *domain.Order) error {
+	for _, r := range Rows(o) {
+		if err := n.enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *ndjsonWriter) Flush() error { return nil }
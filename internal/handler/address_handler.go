@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/service"
+	"github.com/rseigha/goecomapi/pkg/response"
+)
+
+// AddressHandler serves the signed-in user's address book.
+type AddressHandler struct {
+	svc service.UserService
+}
+
+func NewAddressHandler(s service.UserService) *AddressHandler {
+	return &AddressHandler{svc: s}
+}
+
+func (h *AddressHandler) List(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value("user_id").(string)
+	if !ok || uid == "" {
+		response.JSON(w, http.StatusUnauthorized, response.APIResponse{Status: "error", Error: "unauthorized"})
+		return
+	}
+	addrs, err := h.svc.ListAddresses(r.Context(), uid)
+	if err != nil {
+		response.JSON(w, addressErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: addrs})
+}
+
+func (h *AddressHandler) Create(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value("user_id").(string)
+	if !ok || uid == "" {
+		response.JSON(w, http.StatusUnauthorized, response.APIResponse{Status: "error", Error: "unauthorized"})
+		return
+	}
+	var a domain.Address
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	out, err := h.svc.AddAddress(r.Context(), uid, a)
+	if err != nil {
+		response.JSON(w, addressErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusCreated, response.APIResponse{Status: "success", Data: out})
+}
+
+func (h *AddressHandler) Update(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value("user_id").(string)
+	if !ok || uid == "" {
+		response.JSON(w, http.StatusUnauthorized, response.APIResponse{Status: "error", Error: "unauthorized"})
+		return
+	}
+	var a domain.Address
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	// the path ID is authoritative
+	a.ID = mux.Vars(r)["addressId"]
+	out, err := h.svc.UpdateAddress(r.Context(), uid, a)
+	if err != nil {
+		response.JSON(w, addressErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: out})
+}
+
+func (h *AddressHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value("user_id").(string)
+	if !ok || uid == "" {
+		response.JSON(w, http.StatusUnauthorized, response.APIResponse{Status: "error", Error: "unauthorized"})
+		return
+	}
+	if err := h.svc.DeleteAddress(r.Context(), uid, mux.Vars(r)["addressId"]); err != nil {
+		response.JSON(w, addressErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: "address deleted successfully"})
+}
+
+func addressErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAddressNotFound), errors.Is(err, service.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidAddress):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -23,14 +23,16 @@ func NewAuthHandler(as service.AuthService, logger *zap.Logger) *AuthHandler {
 }
 
 type registerRequest struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	CartToken string `json:"cart_token"`
 }
 
 type loginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	CartToken string `json:"cart_token"`
 }
 
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request)  {
@@ -40,7 +42,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request)  {
 		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
 		return
 	}
-	user, err := h.authService.Register(ctx, req.Name, req.Email, req.Password)
+	user, err := h.authService.Register(ctx, req.Name, req.Email, req.Password, req.CartToken)
 	if err != nil {
 		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: err.Error()})
 		return
@@ -56,7 +58,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
 		return
 	}
-	token, err := h.authService.Login(ctx, req.Email, req.Password)
+	token, err := h.authService.Login(ctx, req.Email, req.Password, req.CartToken)
 	if err != nil {
 		response.JSON(w, http.StatusUnauthorized, response.APIResponse{Status: "error", Error: "invalid credentials"})
 		return
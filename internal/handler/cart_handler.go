@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/service"
+	"github.com/rseigha/goecomapi/pkg/response"
+)
+
+// CartTokenHeader carries the guest cart token for anonymous shoppers.
+const CartTokenHeader = "X-Cart-Token"
+
+type CartHandler struct {
+	svc service.CartService
+}
+
+func NewCartHandler(s service.CartService) *CartHandler {
+	return &CartHandler{svc: s}
+}
+
+type cartItemRequest struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// cartOwner identifies the cart from the authenticated user, falling back to
+// the guest token header for anonymous requests.
+func cartOwner(r *http.Request) domain.CartOwner {
+	if uid, ok := r.Context().Value("user_id").(string); ok && uid != "" {
+		return domain.CartOwner{UserID: uid}
+	}
+	return domain.CartOwner{Token: r.Header.Get(CartTokenHeader)}
+}
+
+func writeCart(w http.ResponseWriter, c *domain.Cart) {
+	if c.Token != "" {
+		w.Header().Set(CartTokenHeader, c.Token)
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: c})
+}
+
+func (h *CartHandler) Get(w http.ResponseWriter, r *http.Request) {
+	c, err := h.svc.Get(r.Context(), cartOwner(r))
+	if err != nil {
+		response.JSON(w, cartErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	writeCart(w, c)
+}
+
+func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	var req cartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	c, err := h.svc.AddItem(r.Context(), cartOwner(r), req.ProductID, req.Quantity)
+	if err != nil {
+		response.JSON(w, cartErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	writeCart(w, c)
+}
+
+func (h *CartHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	var req cartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	c, err := h.svc.UpdateItem(r.Context(), cartOwner(r), mux.Vars(r)["productId"], req.Quantity)
+	if err != nil {
+		response.JSON(w, cartErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	writeCart(w, c)
+}
+
+func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	c, err := h.svc.RemoveItem(r.Context(), cartOwner(r), mux.Vars(r)["productId"])
+	if err != nil {
+		response.JSON(w, cartErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	writeCart(w, c)
+}
+
+func (h *CartHandler) Clear(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.Clear(r.Context(), cartOwner(r)); err != nil {
+		response.JSON(w, cartErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success"})
+}
+
+func cartErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrCartItemNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidQuantity), errors.Is(err, service.ErrUnknownProduct):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/service"
+	"github.com/rseigha/goecomapi/pkg/response"
+)
+
+type CheckoutHandler struct {
+	svc service.CheckoutService
+}
+
+func NewCheckoutHandler(s service.CheckoutService) *CheckoutHandler {
+	return &CheckoutHandler{svc: s}
+}
+
+type checkoutRequest struct {
+	// ExpectedTotal is the total the shopper was shown; optional.
+	ExpectedTotal *domain.Money `json:"expected_total"`
+	CouponCode    string        `json:"coupon_code"`
+	// An address holding only an id refers to the user's address book.
+	ShippingAddress *domain.Address `json:"shipping_address"`
+	BillingAddress  *domain.Address `json:"billing_address"`
+	ShippingMethod  string          `json:"shipping_method"`
+}
+
+func (h *CheckoutHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	uid, ok := ctx.Value("user_id").(string)
+	if !ok || uid == "" {
+		response.JSON(w, http.StatusUnauthorized, response.APIResponse{Status: "error", Error: "unauthorized"})
+		return
+	}
+	var req checkoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	o, err := h.svc.Checkout(ctx, uid, service.CheckoutOptions{
+		CouponCode:      req.CouponCode,
+		ExpectedTotal:   req.ExpectedTotal,
+		ShippingAddress: req.ShippingAddress,
+		BillingAddress:  req.BillingAddress,
+		ShippingMethod:  req.ShippingMethod,
+	})
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+	response.JSON(w, http.StatusCreated, response.APIResponse{Status: "success", Data: o})
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/service"
+	"github.com/rseigha/goecomapi/pkg/response"
+)
+
+type CouponHandler struct {
+	svc service.CouponService
+}
+
+func NewCouponHandler(s service.CouponService) *CouponHandler {
+	return &CouponHandler{svc: s}
+}
+
+func (h *CouponHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var c domain.Coupon
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	if err := h.svc.Create(r.Context(), &c); err != nil {
+		response.JSON(w, couponErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusCreated, response.APIResponse{Status: "success", Data: c})
+}
+
+func (h *CouponHandler) Get(w http.ResponseWriter, r *http.Request) {
+	c, err := h.svc.GetByID(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		response.JSON(w, couponErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: c})
+}
+
+func (h *CouponHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	page, _ := strconv.Atoi(q.Get("page"))
+	coupons, total, err := h.svc.List(r.Context(), limit, page)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: map[string]interface{}{
+		"items": coupons, "total": total, "page": page, "limit": limit,
+	}})
+}
+
+func (h *CouponHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var c domain.Coupon
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	// the path ID is authoritative
+	c.ID = mux.Vars(r)["id"]
+	if err := h.svc.Update(r.Context(), &c); err != nil {
+		response.JSON(w, couponErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	out, err := h.svc.GetByID(r.Context(), c.ID)
+	if err != nil {
+		response.JSON(w, couponErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: out})
+}
+
+func (h *CouponHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.Delete(r.Context(), mux.Vars(r)["id"]); err != nil {
+		response.JSON(w, couponErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: "coupon deleted successfully"})
+}
+
+func couponErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrCouponNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidCoupon):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrDuplicateCoupon):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
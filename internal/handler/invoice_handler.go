@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/invoice"
+	"github.com/rseigha/goecomapi/internal/service"
+	"github.com/rseigha/goecomapi/pkg/response"
+	"go.uber.org/zap"
+)
+
+type InvoiceHandler struct {
+	svc    service.InvoiceService
+	logger *zap.Logger
+}
+
+func NewInvoiceHandler(s service.InvoiceService, logger *zap.Logger) *InvoiceHandler {
+	return &InvoiceHandler{svc: s, logger: logger}
+}
+
+// Get downloads the invoice of a paid order as a PDF, or as an HTML page
+// with ?format=html.
+func (h *InvoiceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	uid, ok := ctx.Value("user_id").(string)
+	if !ok || uid == "" {
+		response.JSON(w, http.StatusUnauthorized, response.APIResponse{Status: "error", Error: "unauthorized"})
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "pdf" && format != "html" {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "format must be pdf or html"})
+		return
+	}
+	role, _ := ctx.Value("user_role").(string)
+	inv, err := h.svc.ForOrder(ctx, mux.Vars(r)["id"], uid, role == string(domain.RoleAdmin))
+	if err != nil {
+		response.JSON(w, invoiceErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+
+	if format == "html" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := invoice.HTML(w, inv); err != nil {
+			h.logger.Error("could not render invoice", zap.String("invoice", inv.Number), zap.Error(err))
+		}
+		return
+	}
+	pdf, err := invoice.PDF(inv)
+	if err != nil {
+		h.logger.Error("could not render invoice", zap.String("invoice", inv.Number), zap.Error(err))
+		response.JSON(w, http.StatusInternalServerError, response.APIResponse{Status: "error", Error: "could not render invoice"})
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", inv.Number+".pdf"))
+	w.WriteHeader(http.StatusOK)
+	w.Write(pdf)
+}
+
+func invoiceErrorStatus(err error) int {
+	if errors.Is(err, service.ErrOrderNotInvoiceable) {
+		return http.StatusConflict
+	}
+	return orderErrorStatus(err)
+}
@@ -2,9 +2,16 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/export"
 	"github.com/rseigha/goecomapi/internal/service"
 	"github.com/rseigha/goecomapi/pkg/response"
 )
@@ -17,10 +24,24 @@ func NewOrderHandler(s service.OrderService) *OrderHandler {
 	return &OrderHandler{svc: s}
 }
 
+type orderItemRequest struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+type createOrderRequest struct {
+	Items      []orderItemRequest `json:"items"`
+	CouponCode string             `json:"coupon_code"`
+	// An address holding only an id refers to the user's address book.
+	ShippingAddress *domain.Address `json:"shipping_address"`
+	BillingAddress  *domain.Address `json:"billing_address"`
+	ShippingMethod  string          `json:"shipping_method"`
+}
+
 func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	var o domain.Order
-	if err := json.NewDecoder(r.Body).Decode(&o); err != nil {
+	var req createOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
 		return
 	}
@@ -30,9 +51,18 @@ func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
 		response.JSON(w, http.StatusUnauthorized, response.APIResponse{Status: "error", Error: "unauthorized"})
 		return
 	}
-	o.UserID = uid
+	o := domain.Order{
+		UserID:          uid,
+		CouponCode:      req.CouponCode,
+		ShippingAddress: req.ShippingAddress,
+		BillingAddress:  req.BillingAddress,
+		ShippingMethod:  req.ShippingMethod,
+	}
+	for _, it := range req.Items {
+		o.Items = append(o.Items, domain.OrderItem{ProductID: it.ProductID, Quantity: it.Quantity})
+	}
 	if err := h.svc.CreateOrder(ctx, &o); err != nil {
-		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: err.Error()})
+		writeOrderError(w, err)
 		return
 	}
 	response.JSON(w, http.StatusCreated, response.APIResponse{Status: "success", Data: o})
@@ -45,10 +75,274 @@ func (h *OrderHandler) ListByUser(w http.ResponseWriter, r *http.Request) {
 		response.JSON(w, http.StatusUnauthorized, response.APIResponse{Status: "error", Error: "unauthorized"})
 		return
 	}
-	orders, err := h.svc.GetByUser(ctx, uid)
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	page, err := h.svc.GetByUser(ctx, uid, domain.OrderStatus(q.Get("status")), limit, q.Get("cursor"))
+	if err != nil {
+		response.JSON(w, orderErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: page})
+}
+
+func (h *OrderHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+	uid, ok := ctx.Value("user_id").(string)
+	if !ok || uid == "" {
+		response.JSON(w, http.StatusUnauthorized, response.APIResponse{Status: "error", Error: "unauthorized"})
+		return
+	}
+	role, _ := ctx.Value("user_role").(string)
+	o, err := h.svc.GetForUser(ctx, id, uid, role == string(domain.RoleAdmin))
+	if err != nil {
+		response.JSON(w, orderErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: o})
+}
+
+// GetByNumber looks an order up by its human-readable order number.
+func (h *OrderHandler) GetByNumber(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	uid, ok := ctx.Value("user_id").(string)
+	if !ok || uid == "" {
+		response.JSON(w, http.StatusUnauthorized, response.APIResponse{Status: "error", Error: "unauthorized"})
+		return
+	}
+	role, _ := ctx.Value("user_role").(string)
+	o, err := h.svc.GetByNumberForUser(ctx, mux.Vars(r)["number"], uid, role == string(domain.RoleAdmin))
+	if err != nil {
+		response.JSON(w, orderErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: o})
+}
+
+type updateStatusRequest struct {
+	Status domain.OrderStatus `json:"status"`
+	Reason string             `json:"reason"`
+}
+
+func (h *OrderHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+	var req updateStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	uid, _ := ctx.Value("user_id").(string)
+	o, err := h.svc.UpdateStatus(ctx, id, req.Status, uid, req.Reason)
+	if err != nil {
+		response.JSON(w, orderErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: o})
+}
+
+type cancelOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *OrderHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+	uid, ok := ctx.Value("user_id").(string)
+	if !ok || uid == "" {
+		response.JSON(w, http.StatusUnauthorized, response.APIResponse{Status: "error", Error: "unauthorized"})
+		return
+	}
+	role, _ := ctx.Value("user_role").(string)
+	var req cancelOrderRequest
+	// the body is optional; an empty one means no reason was given
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	o, err := h.svc.Cancel(ctx, id, uid, role == string(domain.RoleAdmin), req.Reason)
 	if err != nil {
-		response.JSON(w, http.StatusInternalServerError, response.APIResponse{Status: "error", Error: err.Error()})
+		response.JSON(w, orderErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
 		return
 	}
-	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: orders})
-}
\ No newline at end of file
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: o})
+}
+
+// Search lists orders across all users. It is meant for admins only.
+func (h *OrderHandler) Search(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	f, err := parseOrderFilter(r.URL.Query())
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	orders, total, err := h.svc.Search(ctx, &f)
+	if err != nil {
+		response.JSON(w, orderErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: map[string]interface{}{
+		"items": orders, "total": total, "page": f.Page, "limit": f.Limit,
+	}})
+}
+
+// Export streams the orders matching the search filters as CSV or NDJSON,
+// one row per line item, oldest first. Errors found before the first row
+// get a normal error response; a failure mid-stream aborts the connection
+// so a truncated file is never mistaken for a complete one.
+func (h *OrderHandler) Export(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	format := export.Format(q.Get("format"))
+	if format == "" {
+		format = export.CSV
+	}
+	f, err := parseOrderFilter(q)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	ew, err := export.NewWriter(w, format)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "format must be csv or ndjson"})
+		return
+	}
+
+	// the server's write timeout is sized for ordinary requests; an export
+	// takes as long as it takes
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+	started := false
+	start := func() {
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Header().Set("Content-Disposition", `attachment; filename="orders-`+time.Now().UTC().Format("20060102")+"."+string(format)+`"`)
+		w.WriteHeader(http.StatusOK)
+		started = true
+	}
+	n := 0
+	err = h.svc.Export(ctx, f, func(o *domain.Order) error {
+		if !started {
+			start()
+		}
+		if err := ew.Write(o); err != nil {
+			return err
+		}
+		if n++; n%exportFlushEvery == 0 {
+			if err := ew.Flush(); err != nil {
+				return err
+			}
+			_ = rc.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if !started {
+			response.JSON(w, orderErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+			return
+		}
+		panic(http.ErrAbortHandler)
+	}
+	if !started {
+		start()
+	}
+	if err := ew.Flush(); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// exportFlushEvery is how many orders an export writes between flushes to
+// the client.
+const exportFlushEvery = 100
+
+func parseOrderFilter(q url.Values) (domain.OrderFilter, error) {
+	f := domain.OrderFilter{
+		Status:    domain.OrderStatus(q.Get("status")),
+		UserID:    q.Get("user_id"),
+		ProductID: q.Get("product_id"),
+		Currency:  q.Get("currency"),
+		Sort:      q.Get("sort"),
+	}
+	f.Limit, _ = strconv.Atoi(q.Get("limit"))
+	f.Page, _ = strconv.Atoi(q.Get("page"))
+
+	var err error
+	if f.CreatedFrom, err = parseTimeParam(q, "created_from", false); err != nil {
+		return f, err
+	}
+	if f.CreatedTo, err = parseTimeParam(q, "created_to", true); err != nil {
+		return f, err
+	}
+	if f.MinTotal, err = parseIntParam(q, "min_total"); err != nil {
+		return f, err
+	}
+	if f.MaxTotal, err = parseIntParam(q, "max_total"); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+// parseTimeParam accepts RFC 3339 timestamps or plain dates. A plain date
+// used as an upper bound covers the whole day.
+func parseTimeParam(q url.Values, name string, upper bool) (*time.Time, error) {
+	v := q.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return nil, errors.New("invalid " + name + ": use YYYY-MM-DD or RFC 3339")
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+func parseIntParam(q url.Values, name string) (*int64, error) {
+	v := q.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid " + name)
+	}
+	return &n, nil
+}
+
+// writeOrderError responds with the status matching an order error. Stock
+// shortages also list the lines that could not be satisfied.
+func writeOrderError(w http.ResponseWriter, err error) {
+	var stockErr *service.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		response.JSON(w, http.StatusConflict, response.APIResponse{Status: "error", Error: err.Error(), Data: stockErr.Items})
+		return
+	}
+	response.JSON(w, orderErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+}
+
+// orderErrorStatus maps order service errors to HTTP status codes.
+func orderErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound), errors.Is(err, service.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidTransition), errors.Is(err, service.ErrInsufficientStock),
+		errors.Is(err, service.ErrPriceChanged), errors.Is(err, service.ErrCouponLimitReached):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidStatus), errors.Is(err, service.ErrInvalidFilter),
+		errors.Is(err, service.ErrEmptyOrder), errors.Is(err, service.ErrInvalidQuantity),
+		errors.Is(err, service.ErrUnknownProduct), errors.Is(err, service.ErrMixedCurrency),
+		errors.Is(err, service.ErrEmptyCart), errors.Is(err, service.ErrCouponNotFound),
+		errors.Is(err, service.ErrCouponNotApplicable), errors.Is(err, service.ErrInvalidAddress),
+		errors.Is(err, service.ErrAddressNotFound), errors.Is(err, service.ErrShippingMethodNotFound),
+		errors.Is(err, service.ErrShippingUnavailable):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrRefundFailed):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
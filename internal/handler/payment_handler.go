@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/service"
+	"github.com/rseigha/goecomapi/pkg/response"
+	"go.uber.org/zap"
+)
+
+const maxWebhookBody = 1 << 20
+
+type PaymentHandler struct {
+	svc    service.PaymentService
+	logger *zap.Logger
+}
+
+func NewPaymentHandler(s service.PaymentService, logger *zap.Logger) *PaymentHandler {
+	return &PaymentHandler{svc: s, logger: logger}
+}
+
+type startPaymentRequest struct {
+	Provider string `json:"provider"`
+}
+
+func (h *PaymentHandler) Start(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	uid, ok := ctx.Value("user_id").(string)
+	if !ok || uid == "" {
+		response.JSON(w, http.StatusUnauthorized, response.APIResponse{Status: "error", Error: "unauthorized"})
+		return
+	}
+	var req startPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	p, err := h.svc.StartPayment(ctx, mux.Vars(r)["id"], uid, req.Provider)
+	if err != nil {
+		response.JSON(w, paymentErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusCreated, response.APIResponse{Status: "success", Data: p})
+}
+
+func (h *PaymentHandler) ListByOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	uid, ok := ctx.Value("user_id").(string)
+	if !ok || uid == "" {
+		response.JSON(w, http.StatusUnauthorized, response.APIResponse{Status: "error", Error: "unauthorized"})
+		return
+	}
+	role, _ := ctx.Value("user_role").(string)
+	payments, err := h.svc.ListByOrder(ctx, mux.Vars(r)["id"], uid, role == string(domain.RoleAdmin))
+	if err != nil {
+		response.JSON(w, paymentErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: payments})
+}
+
+// Webhook receives provider notifications. It is public; authenticity is
+// established by the provider's signature check.
+func (h *PaymentHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	provider := mux.Vars(r)["provider"]
+	if err := h.svc.HandleWebhook(ctx, provider, payload, r.Header); err != nil {
+		status := paymentErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			h.logger.Error("payment webhook failed", zap.String("provider", provider), zap.Error(err))
+		}
+		response.JSON(w, status, response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success"})
+}
+
+func paymentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrUnknownProvider), errors.Is(err, service.ErrInvalidSignature):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrUnknownPayment):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrOrderNotPayable):
+		return http.StatusConflict
+	default:
+		return orderErrorStatus(err)
+	}
+}
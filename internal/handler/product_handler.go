@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -27,7 +28,11 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := h.svc.Create(ctx, &p); err != nil {
-		response.JSON(w, http.StatusInternalServerError, response.APIResponse{Status: "error", Error: err.Error()})
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidPrice) {
+			status = http.StatusBadRequest
+		}
+		response.JSON(w, status, response.APIResponse{Status: "error", Error: err.Error()})
 		return
 	}
 	response.JSON(w, http.StatusCreated, response.APIResponse{Status: "success", Data: p})
@@ -79,7 +84,13 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 	p.ID = id
 
 	if err := h.svc.Update(ctx, &p); err != nil {
-		response.JSON(w, http.StatusInternalServerError, response.APIResponse{
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidPrice) {
+			status = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrUnknownProduct) {
+			status = http.StatusNotFound
+		}
+		response.JSON(w, status, response.APIResponse{
 			Status: "error",
 			Error:  err.Error(),
 		})
@@ -110,4 +121,31 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		Status: "success",
 		Data:   "product deleted successfully",
 	})
+}
+
+// AdjustStock applies a relative stock change, e.g. {"delta": 25} for a
+// delivery or {"delta": -2} for damaged units.
+func (h *ProductHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Delta int `json:"delta"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request body"})
+		return
+	}
+	p, err := h.svc.AdjustStock(r.Context(), mux.Vars(r)["id"], req.Delta)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidStockAdjustment):
+			status = http.StatusBadRequest
+		case errors.Is(err, service.ErrInsufficientStock):
+			status = http.StatusConflict
+		case errors.Is(err, service.ErrUnknownProduct):
+			status = http.StatusNotFound
+		}
+		response.JSON(w, status, response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: p})
 }
\ No newline at end of file
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/service"
+	"github.com/rseigha/goecomapi/pkg/response"
+)
+
+type PromotionHandler struct {
+	svc service.PromotionService
+}
+
+func NewPromotionHandler(s service.PromotionService) *PromotionHandler {
+	return &PromotionHandler{svc: s}
+}
+
+func (h *PromotionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var p domain.Promotion
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	if err := h.svc.Create(r.Context(), &p); err != nil {
+		response.JSON(w, promotionErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusCreated, response.APIResponse{Status: "success", Data: p})
+}
+
+func (h *PromotionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	p, err := h.svc.GetByID(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		response.JSON(w, promotionErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: p})
+}
+
+func (h *PromotionHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	page, _ := strconv.Atoi(q.Get("page"))
+	promotions, total, err := h.svc.List(r.Context(), limit, page)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: map[string]interface{}{
+		"items": promotions, "total": total, "page": page, "limit": limit,
+	}})
+}
+
+func (h *PromotionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var p domain.Promotion
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	// the path ID is authoritative
+	p.ID = mux.Vars(r)["id"]
+	if err := h.svc.Update(r.Context(), &p); err != nil {
+		response.JSON(w, promotionErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: p})
+}
+
+func (h *PromotionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.Delete(r.Context(), mux.Vars(r)["id"]); err != nil {
+		response.JSON(w, promotionErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: "promotion deleted successfully"})
+}
+
+func promotionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrPromotionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidPromotion):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rseigha/goecomapi/internal/service"
+	"github.com/rseigha/goecomapi/pkg/response"
+)
+
+type RefundHandler struct {
+	svc service.RefundService
+}
+
+func NewRefundHandler(s service.RefundService) *RefundHandler {
+	return &RefundHandler{svc: s}
+}
+
+type refundItemRequest struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+type createRefundRequest struct {
+	// Items selects what to refund; leave it out to refund the whole order.
+	Items   []refundItemRequest `json:"items"`
+	Restock bool                `json:"restock"`
+	Reason  string              `json:"reason"`
+}
+
+func (h *RefundHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req createRefundRequest
+	// the body is optional; an empty one refunds the whole order
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	uid, _ := ctx.Value("user_id").(string)
+	sr := service.RefundRequest{Restock: req.Restock, Reason: req.Reason}
+	for _, it := range req.Items {
+		sr.Items = append(sr.Items, service.RefundItem{ProductID: it.ProductID, Quantity: it.Quantity})
+	}
+	rf, err := h.svc.Refund(ctx, mux.Vars(r)["id"], uid, sr)
+	if err != nil {
+		response.JSON(w, refundErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusCreated, response.APIResponse{Status: "success", Data: rf})
+}
+
+func (h *RefundHandler) ListByOrder(w http.ResponseWriter, r *http.Request) {
+	refunds, err := h.svc.ListByOrder(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		response.JSON(w, refundErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: refunds})
+}
+
+func refundErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidRefund):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrOrderNotRefundable), errors.Is(err, service.ErrRefundExceedsCaptured):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrRefundFailed):
+		return http.StatusBadGateway
+	default:
+		return orderErrorStatus(err)
+	}
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/service"
+	"github.com/rseigha/goecomapi/pkg/response"
+)
+
+type ReservationHandler struct {
+	svc service.ReservationService
+}
+
+func NewReservationHandler(s service.ReservationService) *ReservationHandler {
+	return &ReservationHandler{svc: s}
+}
+
+// List returns one page of stock reservations, optionally filtered by
+// status and product.
+func (h *ReservationHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	page, _ := strconv.Atoi(q.Get("page"))
+	items, total, err := h.svc.List(r.Context(), domain.ReservationStatus(q.Get("status")), q.Get("product_id"), limit, page)
+	if err != nil {
+		response.JSON(w, reservationErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: map[string]interface{}{
+		"items": items, "total": total, "page": page, "limit": limit,
+	}})
+}
+
+// Stock reports available and reserved units per product.
+func (h *ReservationHandler) Stock(w http.ResponseWriter, r *http.Request) {
+	levels, err := h.svc.StockLevels(r.Context(), r.URL.Query().Get("product_id"))
+	if err != nil {
+		response.JSON(w, reservationErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: levels})
+}
+
+func reservationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidReservationStatus):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrUnknownProduct):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/service"
+	"github.com/rseigha/goecomapi/pkg/response"
+)
+
+type ReturnHandler struct {
+	svc service.ReturnService
+}
+
+func NewReturnHandler(s service.ReturnService) *ReturnHandler {
+	return &ReturnHandler{svc: s}
+}
+
+type returnItemRequest struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+type createReturnRequest struct {
+	Items     []returnItemRequest `json:"items"`
+	Reason    string              `json:"reason"`
+	PhotoURLs []string            `json:"photo_urls"`
+}
+
+// Create opens a return for items of the caller's order.
+func (h *ReturnHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	uid, ok := ctx.Value("user_id").(string)
+	if !ok || uid == "" {
+		response.JSON(w, http.StatusUnauthorized, response.APIResponse{Status: "error", Error: "unauthorized"})
+		return
+	}
+	var req createReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	in := service.ReturnInput{Reason: req.Reason, PhotoURLs: req.PhotoURLs}
+	for _, it := range req.Items {
+		in.Items = append(in.Items, service.ReturnItem{ProductID: it.ProductID, Quantity: it.Quantity})
+	}
+	rt, err := h.svc.Request(ctx, mux.Vars(r)["id"], uid, in)
+	if err != nil {
+		response.JSON(w, returnErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusCreated, response.APIResponse{Status: "success", Data: rt})
+}
+
+func (h *ReturnHandler) ListByUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	uid, ok := ctx.Value("user_id").(string)
+	if !ok || uid == "" {
+		response.JSON(w, http.StatusUnauthorized, response.APIResponse{Status: "error", Error: "unauthorized"})
+		return
+	}
+	returns, err := h.svc.ListByUser(ctx, uid)
+	if err != nil {
+		response.JSON(w, returnErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: returns})
+}
+
+func (h *ReturnHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	uid, ok := ctx.Value("user_id").(string)
+	if !ok || uid == "" {
+		response.JSON(w, http.StatusUnauthorized, response.APIResponse{Status: "error", Error: "unauthorized"})
+		return
+	}
+	role, _ := ctx.Value("user_role").(string)
+	rt, err := h.svc.GetForUser(ctx, mux.Vars(r)["id"], uid, role == string(domain.RoleAdmin))
+	if err != nil {
+		response.JSON(w, returnErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: rt})
+}
+
+type cancelReturnRequest struct {
+	Note string `json:"note"`
+}
+
+func (h *ReturnHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	uid, ok := ctx.Value("user_id").(string)
+	if !ok || uid == "" {
+		response.JSON(w, http.StatusUnauthorized, response.APIResponse{Status: "error", Error: "unauthorized"})
+		return
+	}
+	var req cancelReturnRequest
+	// the body is optional
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	rt, err := h.svc.Cancel(ctx, mux.Vars(r)["id"], uid, req.Note)
+	if err != nil {
+		response.JSON(w, returnErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: rt})
+}
+
+// List lists returns across all users. It is meant for admins only.
+func (h *ReturnHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	page, _ := strconv.Atoi(q.Get("page"))
+	returns, total, err := h.svc.List(r.Context(), domain.ReturnStatus(q.Get("status")), limit, page)
+	if err != nil {
+		response.JSON(w, returnErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: map[string]interface{}{
+		"items": returns, "total": total, "page": page, "limit": limit,
+	}})
+}
+
+type updateReturnStatusRequest struct {
+	Status domain.ReturnStatus `json:"status"`
+	Note   string              `json:"note"`
+}
+
+func (h *ReturnHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req updateReturnStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	uid, _ := ctx.Value("user_id").(string)
+	rt, err := h.svc.UpdateStatus(ctx, mux.Vars(r)["id"], req.Status, uid, req.Note)
+	if err != nil {
+		response.JSON(w, returnErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: rt})
+}
+
+func returnErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrReturnNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidReturn), errors.Is(err, service.ErrInvalidReturnStatus):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrOrderNotReturnable), errors.Is(err, service.ErrInvalidReturnTransition):
+		return http.StatusConflict
+	default:
+		return refundErrorStatus(err)
+	}
+}
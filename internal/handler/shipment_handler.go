@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rseigha/goecomapi/internal/service"
+	"github.com/rseigha/goecomapi/pkg/response"
+)
+
+type ShipmentHandler struct {
+	svc service.FulfillmentService
+}
+
+func NewShipmentHandler(s service.FulfillmentService) *ShipmentHandler {
+	return &ShipmentHandler{svc: s}
+}
+
+type shipmentItemRequest struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+type createShipmentRequest struct {
+	Carrier        string     `json:"carrier"`
+	TrackingNumber string     `json:"tracking_number"`
+	TrackingURL    string     `json:"tracking_url"`
+	ShippedAt      *time.Time `json:"shipped_at"`
+	// Items selects what is in the parcel; leave it out to ship everything
+	// still outstanding.
+	Items []shipmentItemRequest `json:"items"`
+}
+
+func (h *ShipmentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req createShipmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	uid, _ := ctx.Value("user_id").(string)
+	sr := service.ShipmentRequest{
+		Carrier:        req.Carrier,
+		TrackingNumber: req.TrackingNumber,
+		TrackingURL:    req.TrackingURL,
+		ShippedAt:      req.ShippedAt,
+	}
+	for _, it := range req.Items {
+		sr.Items = append(sr.Items, service.ShipmentItem{ProductID: it.ProductID, Quantity: it.Quantity})
+	}
+	sh, err := h.svc.Ship(ctx, mux.Vars(r)["id"], uid, sr)
+	if err != nil {
+		response.JSON(w, shipmentErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusCreated, response.APIResponse{Status: "success", Data: sh})
+}
+
+func (h *ShipmentHandler) ListByOrder(w http.ResponseWriter, r *http.Request) {
+	shipments, err := h.svc.ListShipments(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		response.JSON(w, shipmentErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: shipments})
+}
+
+type updateTrackingRequest struct {
+	Carrier        string     `json:"carrier"`
+	TrackingNumber string     `json:"tracking_number"`
+	TrackingURL    string     `json:"tracking_url"`
+	ShippedAt      *time.Time `json:"shipped_at"`
+}
+
+func (h *ShipmentHandler) UpdateTracking(w http.ResponseWriter, r *http.Request) {
+	var req updateTrackingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	vars := mux.Vars(r)
+	sh, err := h.svc.UpdateTracking(r.Context(), vars["id"], vars["shipmentId"], service.TrackingUpdate{
+		Carrier:        req.Carrier,
+		TrackingNumber: req.TrackingNumber,
+		TrackingURL:    req.TrackingURL,
+		ShippedAt:      req.ShippedAt,
+	})
+	if err != nil {
+		response.JSON(w, shipmentErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: sh})
+}
+
+func shipmentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrShipmentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidShipment):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrOrderNotShippable):
+		return http.StatusConflict
+	default:
+		return orderErrorStatus(err)
+	}
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/service"
+	"github.com/rseigha/goecomapi/pkg/response"
+)
+
+type ShippingHandler struct {
+	svc service.ShippingService
+}
+
+func NewShippingHandler(s service.ShippingService) *ShippingHandler {
+	return &ShippingHandler{svc: s}
+}
+
+// Available lists the shipping methods customers can choose from, optionally
+// only those shipping to ?country=.
+func (h *ShippingHandler) Available(w http.ResponseWriter, r *http.Request) {
+	methods, err := h.svc.Available(r.Context(), r.URL.Query().Get("country"))
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: methods})
+}
+
+func (h *ShippingHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var m domain.ShippingMethod
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	if err := h.svc.Create(r.Context(), &m); err != nil {
+		response.JSON(w, shippingErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusCreated, response.APIResponse{Status: "success", Data: m})
+}
+
+func (h *ShippingHandler) Get(w http.ResponseWriter, r *http.Request) {
+	m, err := h.svc.GetByID(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		response.JSON(w, shippingErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: m})
+}
+
+func (h *ShippingHandler) List(w http.ResponseWriter, r *http.Request) {
+	methods, err := h.svc.List(r.Context())
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: methods})
+}
+
+func (h *ShippingHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var m domain.ShippingMethod
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.APIResponse{Status: "error", Error: "invalid request"})
+		return
+	}
+	// the path ID is authoritative
+	m.ID = mux.Vars(r)["id"]
+	if err := h.svc.Update(r.Context(), &m); err != nil {
+		response.JSON(w, shippingErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	out, err := h.svc.GetByID(r.Context(), m.ID)
+	if err != nil {
+		response.JSON(w, shippingErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: out})
+}
+
+func (h *ShippingHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.Delete(r.Context(), mux.Vars(r)["id"]); err != nil {
+		response.JSON(w, shippingErrorStatus(err), response.APIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	response.JSON(w, http.StatusOK, response.APIResponse{Status: "success", Data: "shipping method deleted successfully"})
+}
+
+func shippingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrShippingMethodNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidShippingMethod):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrDuplicateShippingMethod):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
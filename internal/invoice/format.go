@@ -0,0 +1,89 @@
+// Package invoice renders domain.Invoice documents as PDF and HTML. The PDF
+// writer is self-contained pure Go and uses only the standard PDF fonts, so
+// it needs no external tools or font files.
+package invoice
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+)
+
+const dateLayout = "2006-01-02"
+
+func negAmount(m domain.Money) string {
+	if m.Amount == 0 {
+		return m.Decimal()
+	}
+	return m.Neg().Decimal()
+}
+
+// rate formats a rate in basis points as a percentage, e.g. 825 as 8.25%.
+func rate(bps int64) string {
+	if bps%100 == 0 {
+		return fmt.Sprintf("%d%%", bps/100)
+	}
+	return strings.TrimRight(fmt.Sprintf("%d.%02d", bps/100, bps%100), "0") + "%"
+}
+
+func currency(inv *domain.Invoice) string {
+	return inv.Total.Currency
+}
+
+// addressLines returns the printable lines of a, or nil when a is nil.
+func addressLines(a *domain.Address) []string {
+	if a == nil {
+		return nil
+	}
+	lines := []string{a.Name, a.Line1}
+	if a.Line2 != "" {
+		lines = append(lines, a.Line2)
+	}
+	city := strings.TrimSpace(strings.Join(nonEmpty(a.City, a.State, a.PostalCode), " "))
+	lines = append(lines, city, a.Country)
+	return nonEmpty(lines...)
+}
+
+func nonEmpty(s ...string) []string {
+	out := make([]string, 0, len(s))
+	for _, v := range s {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// totalRow is one line of an invoice's totals block.
+type totalRow struct {
+	Label string
+	Value string
+	Bold  bool
+}
+
+// totals lists the summary rows printed under the invoice lines.
+func totals(inv *domain.Invoice) []totalRow {
+	rows := []totalRow{{Label: "Subtotal", Value: inv.Subtotal.Decimal()}}
+	if inv.Discount.Amount != 0 {
+		label := "Discount"
+		if len(inv.Adjustments) > 0 {
+			names := make([]string, len(inv.Adjustments))
+			for i, a := range inv.Adjustments {
+				names[i] = a.Name
+			}
+			label += " (" + strings.Join(names, ", ") + ")"
+		}
+		rows = append(rows, totalRow{Label: label, Value: negAmount(inv.Discount)})
+	}
+	rows = append(rows, totalRow{Label: "Shipping", Value: inv.Shipping.Decimal()})
+	taxLabel := "Tax"
+	if inv.TaxInclusive {
+		taxLabel = "Included tax"
+	}
+	rows = append(rows,
+		totalRow{Label: taxLabel, Value: inv.Tax.Decimal()},
+		totalRow{Label: "Total " + currency(inv), Value: inv.Total.Decimal(), Bold: true},
+	)
+	return rows
+}
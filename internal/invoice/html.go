@@ -0,0 +1,71 @@
+package invoice
+
+import (
+	"html/template"
+	"io"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+)
+
+var htmlTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"amount":  domain.Money.Decimal,
+	"rate":    rate,
+	"address": addressLines,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Invoice {{.Invoice.Number}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; font-size: 13px; color: #222; max-width: 800px; margin: 40px auto; }
+h1 { font-size: 28px; margin: 0; }
+table { border-collapse: collapse; width: 100%; }
+th, td { padding: 4px 6px; text-align: left; vertical-align: top; }
+.num { text-align: right; }
+.lines th { border-bottom: 1px solid #444; }
+.totals td { padding: 2px 6px; }
+.totals .bold td { font-weight: bold; border-top: 1px solid #444; }
+.parties td { width: 33%; padding: 16px 6px; }
+.header td { padding: 0; }
+</style>
+</head>
+<body>
+{{with .Invoice}}
+<table class="header">
+<tr>
+<td><h1>INVOICE</h1></td>
+<td class="num">
+Invoice number <strong>{{.Number}}</strong><br>
+Issue date <strong>{{.IssuedAt.Format "2006-01-02"}}</strong><br>
+{{if .OrderNumber}}Order number <strong>{{.OrderNumber}}</strong><br>{{end}}
+Currency <strong>{{.Total.Currency}}</strong>
+</td>
+</tr>
+</table>
+<table class="parties">
+<tr>
+<td><strong>From</strong><br>{{.Seller.Name}}{{range .Seller.Address}}<br>{{.}}{{end}}{{if .Seller.TaxID}}<br>Tax ID: {{.Seller.TaxID}}{{end}}{{if .Seller.Email}}<br>{{.Seller.Email}}{{end}}</td>
+<td><strong>Bill to</strong>{{range address .BillTo}}<br>{{.}}{{end}}</td>
+<td><strong>Ship to</strong>{{range address .ShipTo}}<br>{{.}}{{end}}</td>
+</tr>
+</table>
+<table class="lines">
+<tr><th>Description</th><th>SKU</th><th class="num">Qty</th><th class="num">Unit price</th><th class="num">Tax rate</th><th class="num">Tax</th><th class="num">Amount</th></tr>
+{{range .Lines}}<tr><td>{{.Description}}</td><td>{{.SKU}}</td><td class="num">{{.Quantity}}</td><td class="num">{{amount .UnitPrice}}</td><td class="num">{{rate .TaxRateBPS}}</td><td class="num">{{amount .Tax}}</td><td class="num">{{amount .Amount}}</td></tr>
+{{end}}</table>
+{{end}}
+<table class="totals">
+{{range .Totals}}<tr{{if .Bold}} class="bold"{{end}}><td class="num">{{.Label}}</td><td class="num" style="width: 120px">{{.Value}}</td></tr>
+{{end}}</table>
+{{if .Invoice.TaxInclusive}}<p>Prices include tax.</p>{{end}}
+</body>
+</html>
+`))
+
+// HTML renders inv as a standalone HTML page.
+func HTML(w io.Writer, inv *domain.Invoice) error {
+	return htmlTemplate.Execute(w, struct {
+		Invoice *domain.Invoice
+		Totals  []totalRow
+	}{inv, totals(inv)})
+}
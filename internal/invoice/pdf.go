@@ -0,0 +1,320 @@
+package invoice
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+)
+
+// A4 page geometry, in points.
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	marginLeft   = 50
+	marginRight  = pageWidth - 50
+	marginTop    = pageHeight - 50
+	marginBottom = 60
+	bodySize     = 9
+	lineHeight   = 13
+)
+
+// invoice line columns; numeric columns are right-aligned at their x
+const (
+	colDescription = marginLeft
+	colSKU         = 245
+	colQuantity    = 365
+	colUnitPrice   = 425
+	colTaxRate     = 470
+	colTax         = 515
+	colAmount      = marginRight
+)
+
+type font int
+
+const (
+	regular font = iota
+	bold
+)
+
+// pdfDoc lays text out on pages. Only the two standard Helvetica fonts are
+// used, so nothing has to be embedded.
+type pdfDoc struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64
+	// header is redrawn at the top of every page after the first
+	header func()
+}
+
+func (d *pdfDoc) newPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+	d.y = marginTop
+	if d.header != nil && len(d.pages) > 1 {
+		d.header()
+	}
+}
+
+// need starts a new page unless there are at least n more lines left.
+func (d *pdfDoc) need(n int) {
+	if d.y-float64(n*lineHeight) < marginBottom {
+		d.newPage()
+	}
+}
+
+func (d *pdfDoc) text(x, y float64, f font, size float64, s string) {
+	fmt.Fprintf(d.page, "BT /F%d %g Tf %.2f %.2f Td (%s) Tj ET\n", f+1, size, x, y, pdfString(s))
+}
+
+// textRight draws s so that it ends at x.
+func (d *pdfDoc) textRight(x, y float64, f font, size float64, s string) {
+	d.text(x-textWidth(s, f, size), y, f, size, s)
+}
+
+func (d *pdfDoc) rule(y float64) {
+	fmt.Fprintf(d.page, "0.6 w %d %.2f m %d %.2f l S\n", marginLeft, y, marginRight, y)
+}
+
+// PDF renders inv as a PDF document.
+func PDF(inv *domain.Invoice) ([]byte, error) {
+	d := &pdfDoc{}
+	d.newPage()
+
+	// title and invoice details
+	d.text(marginLeft, d.y-14, bold, 20, "INVOICE")
+	details := [][2]string{
+		{"Invoice number", inv.Number},
+		{"Issue date", inv.IssuedAt.Format(dateLayout)},
+	}
+	if inv.OrderNumber != "" {
+		details = append(details, [2]string{"Order number", inv.OrderNumber})
+	}
+	details = append(details, [2]string{"Currency", currency(inv)})
+	y := d.y
+	for _, kv := range details {
+		d.textRight(440, y, regular, bodySize, kv[0])
+		d.textRight(colAmount, y, bold, bodySize, kv[1])
+		y -= lineHeight
+	}
+	d.y = min(y, d.y-30) - lineHeight
+
+	// seller, bill-to and ship-to blocks side by side
+	seller := append([]string{}, inv.Seller.Address...)
+	if inv.Seller.TaxID != "" {
+		seller = append(seller, "Tax ID: "+inv.Seller.TaxID)
+	}
+	if inv.Seller.Email != "" {
+		seller = append(seller, inv.Seller.Email)
+	}
+	blocks := []struct {
+		title string
+		lines []string
+	}{
+		{"From", append([]string{inv.Seller.Name}, seller...)},
+		{"Bill to", addressLines(inv.BillTo)},
+		{"Ship to", addressLines(inv.ShipTo)},
+	}
+	tallest := 0
+	for i, b := range blocks {
+		x := float64(marginLeft + i*165)
+		d.text(x, d.y, bold, bodySize, b.title)
+		for j, l := range b.lines {
+			d.text(x, d.y-float64((j+1)*lineHeight), regular, bodySize, fit(l, regular, bodySize, 155))
+		}
+		tallest = max(tallest, len(b.lines))
+	}
+	d.y -= float64((tallest + 2) * lineHeight)
+
+	// invoice lines
+	header := func() {
+		d.text(colDescription, d.y, bold, bodySize, "Description")
+		d.text(colSKU, d.y, bold, bodySize, "SKU")
+		d.textRight(colQuantity, d.y, bold, bodySize, "Qty")
+		d.textRight(colUnitPrice, d.y, bold, bodySize, "Unit price")
+		d.textRight(colTaxRate, d.y, bold, bodySize, "Tax rate")
+		d.textRight(colTax, d.y, bold, bodySize, "Tax")
+		d.textRight(colAmount, d.y, bold, bodySize, "Amount")
+		d.rule(d.y - 4)
+		d.y -= lineHeight + 2
+	}
+	d.header = header
+	header()
+	for _, l := range inv.Lines {
+		d.need(1)
+		d.text(colDescription, d.y, regular, bodySize, fit(l.Description, regular, bodySize, colSKU-colDescription-8))
+		d.text(colSKU, d.y, regular, bodySize, fit(l.SKU, regular, bodySize, 80))
+		d.textRight(colQuantity, d.y, regular, bodySize, fmt.Sprint(l.Quantity))
+		d.textRight(colUnitPrice, d.y, regular, bodySize, l.UnitPrice.Decimal())
+		d.textRight(colTaxRate, d.y, regular, bodySize, rate(l.TaxRateBPS))
+		d.textRight(colTax, d.y, regular, bodySize, l.Tax.Decimal())
+		d.textRight(colAmount, d.y, regular, bodySize, l.Amount.Decimal())
+		d.y -= lineHeight
+	}
+	d.header = nil
+
+	// totals
+	rows := totals(inv)
+	d.need(len(rows) + 3)
+	d.rule(d.y + lineHeight - 4)
+	d.y -= 4
+	for _, r := range rows {
+		f := regular
+		if r.Bold {
+			f = bold
+		}
+		d.textRight(colTax, d.y, f, bodySize, fit(r.Label, f, bodySize, colTax-marginLeft))
+		d.textRight(colAmount, d.y, f, bodySize, r.Value)
+		d.y -= lineHeight
+	}
+	if inv.TaxInclusive {
+		d.y -= lineHeight
+		d.text(marginLeft, d.y, regular, bodySize, "Prices include tax.")
+	}
+
+	for i, p := range d.pages {
+		d.page = p
+		d.textRight(colAmount, marginBottom-25, regular, 8, fmt.Sprintf("%s - page %d of %d", inv.Number, i+1, len(d.pages)))
+	}
+	return d.encode("Invoice " + inv.Number)
+}
+
+// encode assembles the pages into a PDF file.
+func (d *pdfDoc) encode(title string) ([]byte, error) {
+	var objs [][]byte
+	add := func(s string) int {
+		objs = append(objs, []byte(s))
+		return len(objs)
+	}
+	catalog := add("") // filled in once the page tree is known
+	pagesObj := add("")
+	f1 := add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	f2 := add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	info := add(fmt.Sprintf("<< /Title (%s) /Producer (goecomapi) >>", pdfString(title)))
+
+	kids := make([]string, 0, len(d.pages))
+	for _, p := range d.pages {
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		if _, err := zw.Write(p.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		content := add(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", z.Len(), z.Bytes()))
+		page := add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> >> /Contents %d 0 R >>",
+			pagesObj, pageWidth, pageHeight, f1, f2, content))
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+	objs[catalog-1] = []byte(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObj))
+	objs[pagesObj-1] = []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, catalog, info, xref)
+	return out.Bytes(), nil
+}
+
+// winAnsi maps the non-Latin-1 characters of the WinAnsi encoding to their
+// codes. Latin-1 characters map to themselves.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91,
+	'’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98,
+	'™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// encodeText converts s to WinAnsi bytes; characters the standard fonts
+// cannot show become '?'.
+func encodeText(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			out = append(out, byte(r))
+		case winAnsi[r] != 0:
+			out = append(out, winAnsi[r])
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+// pdfString returns s encoded and escaped for use inside a PDF literal
+// string.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, c := range encodeText(s) {
+		switch c {
+		case '\\', '(', ')':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// fit shortens s with an ellipsis until it is at most width points wide.
+func fit(s string, f font, size, width float64) string {
+	if textWidth(s, f, size) <= width {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && textWidth(string(r)+"...", f, size) > width {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "..."
+}
+
+// textWidth returns the width of s in points.
+func textWidth(s string, f font, size float64) float64 {
+	widths := &helveticaWidths
+	if f == bold {
+		widths = &helveticaBoldWidths
+	}
+	total := 0
+	for _, c := range encodeText(s) {
+		if c >= 0x20 && c < 0x7f {
+			total += widths[c-0x20]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// glyph widths of the printable ASCII characters (0x20-0x7e), in 1/1000 em,
+// from the Adobe font metrics of the standard fonts
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
@@ -29,12 +29,34 @@ func JWTAuth(jwt *jwtpkg.JWT, logger *zap.Logger) func(next http.Handler) http.H
 				http.Error(w, "invalid token", http.StatusUnauthorized)
 				return
 			}
-			// attach claims to context
-			ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
-			ctx = context.WithValue(ctx, "user_email", claims.Email)
-			ctx = context.WithValue(ctx, "user_role", claims.Role)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, r.WithContext(withClaims(r.Context(), claims)))
 		}
 		return http.HandlerFunc(fn)
 	}
+}
+
+// OptionalJWTAuth authenticates the request when an Authorization header is
+// present and lets anonymous requests through otherwise. Invalid tokens are
+// still rejected rather than treated as anonymous.
+func OptionalJWTAuth(jwt *jwtpkg.JWT, logger *zap.Logger) func(next http.Handler) http.Handler {
+	required := JWTAuth(jwt, logger)
+	return func(next http.Handler) http.Handler {
+		authed := required(next)
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			authed.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// attach claims to context
+func withClaims(ctx context.Context, claims *jwtpkg.CustomClaims) context.Context {
+	ctx = context.WithValue(ctx, "user_id", claims.UserID)
+	ctx = context.WithValue(ctx, "user_email", claims.Email)
+	ctx = context.WithValue(ctx, "user_role", claims.Role)
+	return ctx
 }
\ No newline at end of file
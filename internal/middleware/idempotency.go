@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/rseigha/goecomapi/internal/repository"
+	"go.uber.org/zap"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// Idempotency replays the stored response when a request is retried with the
+// same Idempotency-Key header. Keys are scoped to the authenticated user, so
+// on authenticated routes it must run after JWTAuth. Anonymous keys are
+// scoped to the client address. Either way a key reused with a different
+// request is rejected rather than replayed. Requests without the header pass
+// straight through.
+//
+// JSON object keys listed in omit are removed from the stored copy of a
+// response, for values such as payment client secrets that must never be
+// persisted; replays come back without them.
+func Idempotency(repo repository.IdempotencyRepository, logger *zap.Logger, omit ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				http.Error(w, "idempotency key too long", http.StatusBadRequest)
+				return
+			}
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "could not read request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			hash := requestHash(r, body)
+			userID, _ := r.Context().Value("user_id").(string)
+			if userID == "" {
+				userID = anonymousScope(r)
+			}
+			// finish bookkeeping even if the client goes away mid-request
+			ctx := context.WithoutCancel(r.Context())
+
+			rec, created, err := repo.Reserve(ctx, userID, key, hash)
+			if err != nil {
+				logger.Error("idempotency reserve failed", zap.Error(err))
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			if !created {
+				switch {
+				case rec.RequestHash != hash:
+					http.Error(w, "idempotency key reused with a different request", http.StatusUnprocessableEntity)
+				case !rec.Completed:
+					http.Error(w, "a request with this idempotency key is in progress", http.StatusConflict)
+				default:
+					for k, v := range rec.Header {
+						w.Header()[k] = v
+					}
+					w.Header().Set("Idempotent-Replayed", "true")
+					w.WriteHeader(rec.StatusCode)
+					w.Write(rec.Body)
+				}
+				return
+			}
+
+			rw := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+			stored := false
+			defer func() {
+				// server errors and panics release the key so the client can retry
+				if !stored {
+					if err := repo.Release(ctx, userID, key); err != nil {
+						logger.Error("idempotency release failed", zap.Error(err))
+					}
+				}
+			}()
+			next.ServeHTTP(rw, r)
+			if rw.status >= http.StatusInternalServerError {
+				return
+			}
+			header, saved := rw.header, rw.body.Bytes()
+			if b, ok := omitJSONKeys(saved, omit); ok {
+				h := http.Header(header).Clone()
+				h.Del("Content-Length")
+				header, saved = h, b
+			}
+			if err := repo.Complete(ctx, userID, key, rw.status, header, saved); err != nil {
+				logger.Error("idempotency complete failed", zap.Error(err))
+				return
+			}
+			stored = true
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// anonymousScope keys the idempotency records of unauthenticated requests
+// (e.g. registration) by client address, so keys chosen by different
+// clients do not collide.
+func anonymousScope(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "anonymous:" + host
+}
+
+// omitJSONKeys removes keys from every object in the JSON document body. It
+// reports false, leaving body alone, when body is not JSON or has none of the
+// keys.
+func omitJSONKeys(body []byte, keys []string) ([]byte, bool) {
+	if len(keys) == 0 {
+		return nil, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, false
+	}
+	var removed bool
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for _, k := range keys {
+				if _, ok := v[k]; ok {
+					delete(v, k)
+					removed = true
+				}
+			}
+			for _, e := range v {
+				walk(e)
+			}
+		case []any:
+			for _, e := range v {
+				walk(e)
+			}
+		}
+	}
+	walk(doc)
+	if !removed {
+		return nil, false
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter passes the response through while keeping a copy of it.
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	header      map[string][]string
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+	w.header = w.ResponseWriter.Header().Clone()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
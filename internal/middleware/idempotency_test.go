@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+	"go.uber.org/zap"
+)
+
+// memIdempotency is an in-memory IdempotencyRepository.
+type memIdempotency map[string]*domain.IdempotencyRecord
+
+func (m memIdempotency) Reserve(ctx context.Context, userID, key, hash string) (*domain.IdempotencyRecord, bool, error) {
+	if rec, ok := m[userID+"|"+key]; ok {
+		return rec, false, nil
+	}
+	rec := &domain.IdempotencyRecord{UserID: userID, Key: key, RequestHash: hash}
+	m[userID+"|"+key] = rec
+	return rec, true, nil
+}
+
+func (m memIdempotency) Complete(ctx context.Context, userID, key string, status int, header map[string][]string, body []byte) error {
+	rec := m[userID+"|"+key]
+	rec.Completed, rec.StatusCode, rec.Header, rec.Body = true, status, header, body
+	return nil
+}
+
+func (m memIdempotency) Release(ctx context.Context, userID, key string) error {
+	delete(m, userID+"|"+key)
+	return nil
+}
+
+func TestIdempotencyAnonymous(t *testing.T) {
+	repo := memIdempotency{}
+	calls := 0
+	h := Idempotency(repo, zap.NewNop(), "client_secret")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"u1","client_secret":"s"}`))
+	}))
+	send := func(addr, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", strings.NewReader(body))
+		r.RemoteAddr = addr
+		r.Header.Set("Idempotency-Key", "k1")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := send("10.0.0.1:5000", `{"email":"a@x"}`); w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), "client_secret") {
+		t.Fatalf("first request = %d %s", w.Code, w.Body)
+	}
+	w := send("10.0.0.1:5001", `{"email":"a@x"}`)
+	if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("retry = %d, replayed %q", w.Code, w.Header().Get("Idempotent-Replayed"))
+	}
+	if strings.Contains(w.Body.String(), "client_secret") {
+		t.Errorf("replay leaked an omitted key: %s", w.Body)
+	}
+	if w := send("10.0.0.1:5002", `{"email":"b@x"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reuse with another body = %d, want 422", w.Code)
+	}
+	if w := send("10.0.0.2:5000", `{"email":"b@x"}`); w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("same key from another client = %d, want a fresh 201", w.Code)
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+}
+
+func TestOmitJSONKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+		ok   bool
+	}{
+		{"nested", `{"status":"success","data":{"id":"p1","client_secret":"s3cr3t"}}`, `{"data":{"id":"p1"},"status":"success"}`, true},
+		{"inside arrays", `[{"client_secret":"a"},{"id":"b"}]`, `[{},{"id":"b"}]`, true},
+		{"numbers kept exact", `{"amount":12345678901234567,"client_secret":"a"}`, `{"amount":12345678901234567}`, true},
+		{"key absent", `{"status":"success"}`, "", false},
+		{"not json", `internal error`, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := omitJSONKeys([]byte(tt.in), []string{"client_secret"})
+			if ok != tt.ok || string(got) != tt.want {
+				t.Errorf("omitJSONKeys(%s) = %s, %v, want %s, %v", tt.in, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,362 @@
+// Package promotion evaluates promotion rules against priced cart or order
+// lines. It has no storage dependencies, so rules can be exercised with
+// plain values.
+package promotion
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+)
+
+// Line is one priced line of a cart or order.
+type Line struct {
+	ProductID string
+	Name      string
+	Quantity  int
+	UnitPrice domain.Money
+}
+
+// Active reports whether p applies at now.
+func Active(p *domain.Promotion, now time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// Evaluate applies the promotions active at now to lines and returns one
+// adjustment per promotion that took money off, in the order they were
+// applied. Promotions run by descending priority. A unit discounted by one
+// promotion is not discounted again by another, so spend thresholds only
+// count the units earlier promotions left alone. Lines priced in another
+// currency than the first line are ignored.
+func Evaluate(promos []*domain.Promotion, lines []Line, now time.Time) []domain.Adjustment {
+	out := []domain.Adjustment{}
+	if len(lines) == 0 {
+		return out
+	}
+	st := newState(lines)
+
+	active := make([]*domain.Promotion, 0, len(promos))
+	for _, p := range promos {
+		if Active(p, now) {
+			active = append(active, p)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		if active[i].Priority != active[j].Priority {
+			return active[i].Priority > active[j].Priority
+		}
+		return active[i].ID < active[j].ID
+	})
+
+	for _, p := range active {
+		var amount int64
+		var desc string
+		switch p.Type {
+		case domain.PromoBuyXGetY:
+			amount, desc = st.buyXGetY(p)
+		case domain.PromoSpendThreshold:
+			amount, desc = st.spendThreshold(p)
+		case domain.PromoBundle:
+			amount, desc = st.bundle(p)
+		case domain.PromoFreeItem:
+			amount, desc = st.freeItem(p)
+		}
+		if amount <= 0 {
+			continue
+		}
+		out = append(out, domain.Adjustment{
+			PromotionID: p.ID,
+			Name:        p.Name,
+			Description: desc,
+			Amount:      domain.NewMoney(amount, st.currency),
+		})
+	}
+	return out
+}
+
+// Validate rejects promotions that could never apply.
+func Validate(p *domain.Promotion) error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("name is required")
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.StartsAt.Before(*p.EndsAt) {
+		return errors.New("starts_at must be before ends_at")
+	}
+	if p.MaxApplications < 0 {
+		return errors.New("max_applications must not be negative")
+	}
+	switch p.Type {
+	case domain.PromoBuyXGetY:
+		if p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return errors.New("buy_quantity and get_quantity must be positive")
+		}
+		if p.PercentBPS <= 0 || p.PercentBPS > 10000 {
+			return errors.New("percent_bps must be between 1 and 10000")
+		}
+	case domain.PromoSpendThreshold:
+		if len(p.Tiers) == 0 {
+			return errors.New("at least one tier is required")
+		}
+		for _, t := range p.Tiers {
+			if t.Threshold.Amount <= 0 {
+				return errors.New("tier thresholds must be positive")
+			}
+			if (t.AmountOff.Amount > 0) == (t.PercentBPS > 0) {
+				return errors.New("each tier needs either amount_off or percent_bps")
+			}
+			if t.PercentBPS > 10000 || t.AmountOff.Amount < 0 {
+				return errors.New("tier discount out of range")
+			}
+			if t.AmountOff.Amount > 0 && t.AmountOff.Currency != t.Threshold.Currency {
+				return errors.New("tier threshold and amount_off must share a currency")
+			}
+		}
+	case domain.PromoBundle:
+		if len(distinct(p.ProductIDs)) < 2 {
+			return errors.New("a bundle needs at least two different products")
+		}
+		if p.BundlePrice.Amount <= 0 {
+			return errors.New("bundle_price must be positive")
+		}
+	case domain.PromoFreeItem:
+		if p.FreeProductID == "" {
+			return errors.New("free_product_id is required")
+		}
+		if p.MinQuantity < 0 || p.FreeQuantity < 0 {
+			return errors.New("quantities must not be negative")
+		}
+	default:
+		return fmt.Errorf("unknown promotion type %q", p.Type)
+	}
+	return nil
+}
+
+// state tracks which units are still undiscounted while promotions run.
+type state struct {
+	lines    []Line
+	currency string
+	// left counts the units of each line no promotion has claimed yet
+	left []int
+}
+
+func newState(lines []Line) *state {
+	st := &state{lines: lines, currency: lines[0].UnitPrice.Currency, left: make([]int, len(lines))}
+	for i, l := range lines {
+		if l.UnitPrice.Currency == st.currency && l.Quantity > 0 {
+			st.left[i] = l.Quantity
+		}
+	}
+	return st
+}
+
+type unit struct {
+	line  int
+	price int64
+}
+
+// units lists the unclaimed units of the matching products, dearest first.
+func (st *state) units(ids []string) []unit {
+	var out []unit
+	for i, l := range st.lines {
+		if !matches(ids, l.ProductID) {
+			continue
+		}
+		for n := 0; n < st.left[i]; n++ {
+			out = append(out, unit{line: i, price: l.UnitPrice.Amount})
+		}
+	}
+	sort.SliceStable(out, func(a, b int) bool { return out[a].price > out[b].price })
+	return out
+}
+
+func (st *state) claim(us []unit) {
+	for _, u := range us {
+		st.left[u.line]--
+	}
+}
+
+func (st *state) name(productID string) string {
+	for _, l := range st.lines {
+		if l.ProductID == productID && l.Name != "" {
+			return l.Name
+		}
+	}
+	return productID
+}
+
+func (st *state) money(amount int64) domain.Money {
+	return domain.NewMoney(amount, st.currency)
+}
+
+// buyXGetY groups the eligible units, dearest first, into sets of
+// BuyQuantity+GetQuantity and discounts the cheapest GetQuantity units of
+// each full set.
+func (st *state) buyXGetY(p *domain.Promotion) (int64, string) {
+	size := p.BuyQuantity + p.GetQuantity
+	if p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+		return 0, ""
+	}
+	us := st.units(p.ProductIDs)
+	sets := capApplications(len(us)/size, p.MaxApplications)
+	var amount int64
+	for s := 0; s < sets; s++ {
+		set := us[s*size : (s+1)*size]
+		for _, u := range set[p.BuyQuantity:] {
+			amount += st.money(u.price).MulRatio(p.PercentBPS, 10000).Amount
+		}
+		st.claim(set)
+	}
+	return amount, fmt.Sprintf("buy %d get %d %s, applied %d time(s)",
+		p.BuyQuantity, p.GetQuantity, percentText(p.PercentBPS), sets)
+}
+
+// spendThreshold applies the highest tier the eligible spend reaches. The
+// spend counts the unclaimed units of the matching products, and the tier's
+// discount claims them.
+func (st *state) spendThreshold(p *domain.Promotion) (int64, string) {
+	us := st.units(p.ProductIDs)
+	var spend int64
+	for _, u := range us {
+		spend += u.price
+	}
+	var best *domain.PromotionTier
+	for i := range p.Tiers {
+		t := &p.Tiers[i]
+		if t.Threshold.Currency != st.currency || t.Threshold.Amount > spend {
+			continue
+		}
+		if best == nil || t.Threshold.Amount > best.Threshold.Amount {
+			best = t
+		}
+	}
+	if best == nil {
+		return 0, ""
+	}
+	var amount int64
+	var off string
+	if best.PercentBPS > 0 {
+		amount = st.money(spend).MulRatio(best.PercentBPS, 10000).Amount
+		off = percentText(best.PercentBPS)
+	} else {
+		if best.AmountOff.Currency != st.currency {
+			return 0, ""
+		}
+		amount = best.AmountOff.Amount
+		off = best.AmountOff.String() + " off"
+	}
+	amount = min(amount, spend)
+	st.claim(us)
+	return amount, fmt.Sprintf("spent %s, reaching the %s tier: %s", st.money(spend), best.Threshold, off)
+}
+
+// bundle sells one unit of each bundled product for BundlePrice, as many
+// times as every product is still available.
+func (st *state) bundle(p *domain.Promotion) (int64, string) {
+	ids := distinct(p.ProductIDs)
+	if len(ids) < 2 || p.BundlePrice.Currency != st.currency {
+		return 0, ""
+	}
+	perProduct := make([][]unit, len(ids))
+	sets := -1
+	for i, id := range ids {
+		perProduct[i] = st.units([]string{id})
+		if sets < 0 || len(perProduct[i]) < sets {
+			sets = len(perProduct[i])
+		}
+	}
+	sets = capApplications(sets, p.MaxApplications)
+	var list int64
+	for _, us := range perProduct {
+		for _, u := range us[:sets] {
+			list += u.price
+		}
+	}
+	amount := list - int64(sets)*p.BundlePrice.Amount
+	if amount <= 0 {
+		// the bundle is no cheaper than buying the items separately
+		return 0, ""
+	}
+	names := make([]string, len(ids))
+	for i, us := range perProduct {
+		st.claim(us[:sets])
+		names[i] = st.name(ids[i])
+	}
+	return amount, fmt.Sprintf("bundle of %s for %s, applied %d time(s)",
+		strings.Join(names, " + "), p.BundlePrice, sets)
+}
+
+// freeItem makes FreeQuantity units of FreeProductID free for every
+// MinQuantity units of the trigger products bought.
+func (st *state) freeItem(p *domain.Promotion) (int64, string) {
+	minQty := max(p.MinQuantity, 1)
+	freeQty := max(p.FreeQuantity, 1)
+	var bought int
+	for _, l := range st.lines {
+		if l.ProductID != p.FreeProductID && l.UnitPrice.Currency == st.currency && matches(p.ProductIDs, l.ProductID) {
+			bought += l.Quantity
+		}
+	}
+	apps := capApplications(bought/minQty, p.MaxApplications)
+	free := st.units([]string{p.FreeProductID})
+	free = free[:min(len(free), apps*freeQty)]
+	var amount int64
+	for _, u := range free {
+		amount += u.price
+	}
+	st.claim(free)
+	return amount, fmt.Sprintf("%d × %s free with purchase", len(free), st.name(p.FreeProductID))
+}
+
+func matches(ids []string, productID string) bool {
+	if len(ids) == 0 {
+		return true
+	}
+	for _, id := range ids {
+		if id == productID {
+			return true
+		}
+	}
+	return false
+}
+
+func distinct(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func capApplications(n, maxApps int) int {
+	if maxApps > 0 && n > maxApps {
+		return maxApps
+	}
+	return n
+}
+
+func percentText(bps int64) string {
+	if bps >= 10000 {
+		return "free"
+	}
+	if bps%100 == 0 {
+		return fmt.Sprintf("%d%% off", bps/100)
+	}
+	return fmt.Sprintf("%d.%02d%% off", bps/100, bps%100)
+}
@@ -0,0 +1,290 @@
+package promotion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+)
+
+var now = time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func usd(amount int64) domain.Money { return domain.NewMoney(amount, "USD") }
+
+func line(productID string, qty int, price int64) Line {
+	return Line{ProductID: productID, Name: productID, Quantity: qty, UnitPrice: usd(price)}
+}
+
+// applied is the promotion ID and amount of one adjustment.
+type applied struct {
+	id     string
+	amount int64
+}
+
+func evaluate(t *testing.T, promos []*domain.Promotion, lines []Line) []applied {
+	t.Helper()
+	adjs := Evaluate(promos, lines, now)
+	out := make([]applied, len(adjs))
+	for i, a := range adjs {
+		if a.Amount.Currency != "USD" {
+			t.Errorf("adjustment %s in %q, want USD", a.PromotionID, a.Amount.Currency)
+		}
+		out[i] = applied{a.PromotionID, a.Amount.Amount}
+	}
+	return out
+}
+
+func check(t *testing.T, got, want []applied) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func buyXGetY(id string, buy, get int, bps int64, ids ...string) *domain.Promotion {
+	return &domain.Promotion{ID: id, Name: id, Type: domain.PromoBuyXGetY, Active: true,
+		BuyQuantity: buy, GetQuantity: get, PercentBPS: bps, ProductIDs: ids}
+}
+
+func TestBuyXGetY(t *testing.T) {
+	capped := buyXGetY("p", 2, 1, 10000)
+	capped.MaxApplications = 1
+	tests := []struct {
+		name  string
+		promo *domain.Promotion
+		lines []Line
+		want  []applied
+	}{
+		{"one full set", buyXGetY("p", 2, 1, 10000), []Line{line("a", 3, 1000)}, []applied{{"p", 1000}}},
+		{"not enough units", buyXGetY("p", 2, 1, 10000), []Line{line("a", 2, 1000)}, nil},
+		{"partial second set ignored", buyXGetY("p", 2, 1, 10000), []Line{line("a", 5, 1000)}, []applied{{"p", 1000}}},
+		{"two sets", buyXGetY("p", 2, 1, 10000), []Line{line("a", 6, 1000)}, []applied{{"p", 2000}}},
+		{"max applications", capped, []Line{line("a", 6, 1000)}, []applied{{"p", 1000}}},
+		{"cheapest unit is free", buyXGetY("p", 2, 1, 10000), []Line{line("a", 2, 1000), line("b", 1, 500)}, []applied{{"p", 500}}},
+		{"sets are built dearest first", buyXGetY("p", 1, 1, 10000), []Line{line("a", 1, 100), line("b", 1, 900), line("c", 1, 500), line("d", 1, 300)}, []applied{{"p", 600}}},
+		{"half price rounds half up", buyXGetY("p", 1, 1, 5000), []Line{line("a", 2, 999)}, []applied{{"p", 500}}},
+		{"only eligible products", buyXGetY("p", 1, 1, 10000, "a"), []Line{line("a", 1, 1000), line("b", 1, 800)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check(t, evaluate(t, []*domain.Promotion{tt.promo}, tt.lines), tt.want)
+		})
+	}
+}
+
+func TestSpendThresholdTiers(t *testing.T) {
+	tiered := func(ids ...string) *domain.Promotion {
+		return &domain.Promotion{ID: "p", Name: "p", Type: domain.PromoSpendThreshold, Active: true, ProductIDs: ids,
+			Tiers: []domain.PromotionTier{
+				{Threshold: usd(5000), AmountOff: usd(500)},
+				{Threshold: usd(10000), PercentBPS: 1000},
+				{Threshold: domain.NewMoney(1, "EUR"), PercentBPS: 9000},
+			}}
+	}
+	tests := []struct {
+		name  string
+		promo *domain.Promotion
+		lines []Line
+		want  []applied
+	}{
+		{"below every tier", tiered(), []Line{line("a", 1, 4999)}, nil},
+		{"exactly the first tier", tiered(), []Line{line("a", 1, 5000)}, []applied{{"p", 500}}},
+		{"first tier", tiered(), []Line{line("a", 2, 3000)}, []applied{{"p", 500}}},
+		{"highest tier reached", tiered(), []Line{line("a", 1, 12345)}, []applied{{"p", 1235}}},
+		{"only eligible spend counts", tiered("a"), []Line{line("a", 1, 3000), line("b", 1, 9000)}, nil},
+		{"eligible spend reaches tier", tiered("a"), []Line{line("a", 2, 3000), line("b", 1, 9000)}, []applied{{"p", 500}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check(t, evaluate(t, []*domain.Promotion{tt.promo}, tt.lines), tt.want)
+		})
+	}
+}
+
+func TestSpendThresholdAmountOffCappedAtSpend(t *testing.T) {
+	p := &domain.Promotion{ID: "p", Name: "p", Type: domain.PromoSpendThreshold, Active: true, ProductIDs: []string{"a"},
+		Tiers: []domain.PromotionTier{{Threshold: usd(100), AmountOff: usd(5000)}}}
+	check(t, evaluate(t, []*domain.Promotion{p}, []Line{line("a", 1, 300)}), []applied{{"p", 300}})
+}
+
+func TestBundle(t *testing.T) {
+	bundle := func(price int64, ids ...string) *domain.Promotion {
+		return &domain.Promotion{ID: "p", Name: "p", Type: domain.PromoBundle, Active: true,
+			ProductIDs: ids, BundlePrice: usd(price)}
+	}
+	tests := []struct {
+		name  string
+		promo *domain.Promotion
+		lines []Line
+		want  []applied
+	}{
+		{"one bundle", bundle(1500, "a", "b"), []Line{line("a", 1, 1000), line("b", 1, 800)}, []applied{{"p", 300}}},
+		{"limited by scarcest product", bundle(1500, "a", "b"), []Line{line("a", 3, 1000), line("b", 1, 800)}, []applied{{"p", 300}}},
+		{"two bundles", bundle(1500, "a", "b"), []Line{line("a", 2, 1000), line("b", 2, 800)}, []applied{{"p", 600}}},
+		{"product missing", bundle(1500, "a", "b"), []Line{line("a", 2, 1000)}, nil},
+		{"not cheaper than separately", bundle(2000, "a", "b"), []Line{line("a", 1, 1000), line("b", 1, 800)}, nil},
+		{"three products", bundle(2000, "a", "b", "c"), []Line{line("a", 1, 1000), line("b", 1, 800), line("c", 1, 700)}, []applied{{"p", 500}}},
+		{"duplicate ids count once", bundle(1500, "a", "a", "b"), []Line{line("a", 1, 1000), line("b", 1, 800)}, []applied{{"p", 300}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check(t, evaluate(t, []*domain.Promotion{tt.promo}, tt.lines), tt.want)
+		})
+	}
+}
+
+func TestFreeItem(t *testing.T) {
+	free := func(minQty, freeQty, maxApps int) *domain.Promotion {
+		return &domain.Promotion{ID: "p", Name: "p", Type: domain.PromoFreeItem, Active: true,
+			ProductIDs: []string{"a"}, FreeProductID: "g", MinQuantity: minQty, FreeQuantity: freeQty, MaxApplications: maxApps}
+	}
+	tests := []struct {
+		name  string
+		promo *domain.Promotion
+		lines []Line
+		want  []applied
+	}{
+		{"one per trigger set", free(2, 1, 0), []Line{line("a", 4, 1000), line("g", 3, 300)}, []applied{{"p", 600}}},
+		{"not enough triggers", free(2, 1, 0), []Line{line("a", 1, 1000), line("g", 1, 300)}, nil},
+		{"free product not in cart", free(2, 1, 0), []Line{line("a", 4, 1000)}, nil},
+		{"limited by free units in cart", free(1, 2, 0), []Line{line("a", 2, 1000), line("g", 1, 300)}, []applied{{"p", 300}}},
+		{"max applications", free(1, 1, 1), []Line{line("a", 3, 1000), line("g", 3, 300)}, []applied{{"p", 300}}},
+		{"defaults to one for one", free(0, 0, 0), []Line{line("a", 2, 1000), line("g", 5, 300)}, []applied{{"p", 600}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check(t, evaluate(t, []*domain.Promotion{tt.promo}, tt.lines), tt.want)
+		})
+	}
+}
+
+func TestPriorityAndClaiming(t *testing.T) {
+	bogo := buyXGetY("bogo", 1, 1, 10000, "a")
+	bundle := &domain.Promotion{ID: "bundle", Name: "bundle", Type: domain.PromoBundle, Active: true,
+		ProductIDs: []string{"a", "b"}, BundlePrice: usd(1500)}
+	lines := []Line{line("a", 2, 1000), line("b", 1, 1000)}
+
+	t.Run("higher priority claims the units first", func(t *testing.T) {
+		bogo.Priority, bundle.Priority = 10, 1
+		check(t, evaluate(t, []*domain.Promotion{bundle, bogo}, lines), []applied{{"bogo", 1000}})
+	})
+	t.Run("reversed priority", func(t *testing.T) {
+		bogo.Priority, bundle.Priority = 1, 10
+		check(t, evaluate(t, []*domain.Promotion{bogo, bundle}, lines), []applied{{"bundle", 500}})
+	})
+	t.Run("equal priority runs in id order", func(t *testing.T) {
+		bogo.Priority, bundle.Priority = 5, 5
+		check(t, evaluate(t, []*domain.Promotion{bundle, bogo}, lines), []applied{{"bogo", 1000}})
+	})
+	t.Run("unclaimed units stay available", func(t *testing.T) {
+		bogo.Priority, bundle.Priority = 10, 1
+		more := []Line{line("a", 3, 1000), line("b", 1, 1000)}
+		check(t, evaluate(t, []*domain.Promotion{bogo, bundle}, more), []applied{{"bogo", 1000}, {"bundle", 500}})
+	})
+}
+
+func TestStackingWithSpendThreshold(t *testing.T) {
+	bogo := buyXGetY("bogo", 1, 1, 10000, "a")
+	bogo.Priority = 10
+	spend := &domain.Promotion{ID: "spend", Name: "spend", Type: domain.PromoSpendThreshold, Active: true, Priority: 1,
+		Tiers: []domain.PromotionTier{{Threshold: usd(5000), PercentBPS: 1000}}}
+	tests := []struct {
+		name  string
+		lines []Line
+		want  []applied
+	}{
+		// both units of a went to the bogo, leaving nothing to count
+		{"claimed units do not count", []Line{line("a", 2, 3000)}, []applied{{"bogo", 3000}}},
+		{"claimed units drop below threshold", []Line{line("a", 2, 3000), line("b", 1, 4000)}, []applied{{"bogo", 3000}}},
+		// only b's 6000 counts and is discounted
+		{"threshold on unclaimed units", []Line{line("a", 2, 3000), line("b", 1, 6000)}, []applied{{"bogo", 3000}, {"spend", 600}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check(t, evaluate(t, []*domain.Promotion{spend, bogo}, tt.lines), tt.want)
+		})
+	}
+}
+
+func TestSpendThresholdClaimsUnits(t *testing.T) {
+	tiered := func(id string, priority int, ids ...string) *domain.Promotion {
+		return &domain.Promotion{ID: id, Name: id, Type: domain.PromoSpendThreshold, Active: true, Priority: priority,
+			ProductIDs: ids, Tiers: []domain.PromotionTier{{Threshold: usd(5000), PercentBPS: 1000}}}
+	}
+	t.Run("product tier skips units claimed earlier", func(t *testing.T) {
+		bogo := buyXGetY("bogo", 1, 1, 10000, "a")
+		bogo.Priority = 10
+		// the bogo claims two units of a; the 3000 left is below the tier
+		got := evaluate(t, []*domain.Promotion{tiered("spend", 1, "a"), bogo}, []Line{line("a", 3, 3000)})
+		check(t, got, []applied{{"bogo", 3000}})
+	})
+	t.Run("later promotions skip units a tier discounted", func(t *testing.T) {
+		bogo := buyXGetY("bogo", 1, 1, 10000, "a")
+		bogo.Priority = 1
+		got := evaluate(t, []*domain.Promotion{tiered("spend", 10), bogo}, []Line{line("a", 2, 3000)})
+		check(t, got, []applied{{"spend", 600}})
+	})
+	t.Run("two tiers never discount the same unit", func(t *testing.T) {
+		got := evaluate(t, []*domain.Promotion{tiered("first", 10), tiered("second", 1)}, []Line{line("a", 2, 6000)})
+		check(t, got, []applied{{"first", 1200}})
+	})
+}
+
+func TestInactivePromotionsSkipped(t *testing.T) {
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+	off := buyXGetY("off", 1, 1, 10000)
+	off.Active = false
+	ended := buyXGetY("ended", 1, 1, 10000)
+	ended.EndsAt = &now
+	future := buyXGetY("future", 1, 1, 10000)
+	future.StartsAt = &after
+	running := buyXGetY("running", 1, 1, 10000)
+	running.StartsAt, running.EndsAt = &before, &after
+
+	got := evaluate(t, []*domain.Promotion{off, ended, future, running}, []Line{line("a", 2, 1000)})
+	check(t, got, []applied{{"running", 1000}})
+}
+
+func TestOtherCurrencyLinesIgnored(t *testing.T) {
+	eur := Line{ProductID: "b", Quantity: 4, UnitPrice: domain.NewMoney(5000, "EUR")}
+	got := evaluate(t, []*domain.Promotion{buyXGetY("p", 1, 1, 10000)}, []Line{line("a", 1, 1000), eur})
+	check(t, got, nil)
+}
+
+func TestEvaluateNoLines(t *testing.T) {
+	adjs := Evaluate([]*domain.Promotion{buyXGetY("p", 1, 1, 10000)}, nil, now)
+	if adjs == nil || len(adjs) != 0 {
+		t.Fatalf("Evaluate() = %v, want an empty slice", adjs)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		promo domain.Promotion
+		ok    bool
+	}{
+		{"buy x get y", domain.Promotion{Name: "n", Type: domain.PromoBuyXGetY, BuyQuantity: 2, GetQuantity: 1, PercentBPS: 10000}, true},
+		{"buy x get y without percent", domain.Promotion{Name: "n", Type: domain.PromoBuyXGetY, BuyQuantity: 2, GetQuantity: 1}, false},
+		{"missing name", domain.Promotion{Type: domain.PromoBuyXGetY, BuyQuantity: 2, GetQuantity: 1, PercentBPS: 10000}, false},
+		{"tier with both discounts", domain.Promotion{Name: "n", Type: domain.PromoSpendThreshold,
+			Tiers: []domain.PromotionTier{{Threshold: usd(100), AmountOff: usd(10), PercentBPS: 100}}}, false},
+		{"tier currencies differ", domain.Promotion{Name: "n", Type: domain.PromoSpendThreshold,
+			Tiers: []domain.PromotionTier{{Threshold: usd(100), AmountOff: domain.NewMoney(10, "EUR")}}}, false},
+		{"bundle of one product", domain.Promotion{Name: "n", Type: domain.PromoBundle, ProductIDs: []string{"a", "a"}, BundlePrice: usd(100)}, false},
+		{"free item", domain.Promotion{Name: "n", Type: domain.PromoFreeItem, FreeProductID: "g"}, true},
+		{"unknown type", domain.Promotion{Name: "n", Type: "mystery"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(&tt.promo); (err == nil) != tt.ok {
+				t.Errorf("Validate() = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
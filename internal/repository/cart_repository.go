@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/database"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+// guestCartTTL is how long an untouched guest cart is kept.
+const guestCartTTL = 30 * 24 * time.Hour
+
+type cartRepo struct {
+	coll   *mongo.Collection
+	logger *zap.Logger
+}
+
+func NewCartRepository(db *database.MongoDB, logger *zap.Logger) CartRepository {
+	c := db.Collection("carts")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	hasUser := bson.M{"user_id": bson.M{"$exists": true}}
+	hasToken := bson.M{"token": bson.M{"$exists": true}}
+	mods := []mongo.IndexModel{
+		// one cart per user and per guest token
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(hasUser),
+		},
+		{
+			Keys:    bson.D{{Key: "token", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(hasToken),
+		},
+		// abandoned guest carts expire; user carts are kept
+		{
+			Keys: bson.D{{Key: "updated_at", Value: 1}},
+			Options: options.Index().
+				SetExpireAfterSeconds(int32(guestCartTTL.Seconds())).
+				SetPartialFilterExpression(hasToken),
+		},
+	}
+	if _, err := c.Indexes().CreateMany(ctx, mods); err != nil {
+		logger.Warn("could not create cart indexes", zap.Error(err))
+	}
+	return &cartRepo{coll: c, logger: logger}
+}
+
+func ownerFilter(owner domain.CartOwner) bson.M {
+	if owner.IsGuest() {
+		return bson.M{"token": owner.Token}
+	}
+	return bson.M{"user_id": owner.UserID}
+}
+
+func (r *cartRepo) Get(ctx context.Context, owner domain.CartOwner) (*domain.Cart, error) {
+	if owner.IsGuest() && owner.Token == "" {
+		return nil, ErrNotFound
+	}
+	var c domain.Cart
+	if err := r.coll.FindOne(ctx, ownerFilter(owner)).Decode(&c); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Save replaces the cart's items, creating the cart on first use.
+func (r *cartRepo) Save(ctx context.Context, c *domain.Cart) error {
+	now := time.Now().UTC()
+	c.UpdatedAt = now
+	res, err := r.coll.UpdateOne(ctx,
+		ownerFilter(domain.CartOwner{UserID: c.UserID, Token: c.Token}),
+		bson.M{
+			"$set":         bson.M{"items": c.Items, "updated_at": now},
+			"$setOnInsert": bson.M{"created_at": now},
+		},
+		options.UpdateOne().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+	if oid, ok := res.UpsertedID.(bson.ObjectID); ok {
+		c.ID = oid.Hex()
+		c.CreatedAt = now
+	}
+	return nil
+}
+
+func (r *cartRepo) Delete(ctx context.Context, owner domain.CartOwner) error {
+	_, err := r.coll.DeleteOne(ctx, ownerFilter(owner))
+	return err
+}
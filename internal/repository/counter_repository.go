@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rseigha/goecomapi/internal/database"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+type counterRepo struct {
+	coll   *mongo.Collection
+	logger *zap.Logger
+}
+
+func NewCounterRepository(db *database.MongoDB, logger *zap.Logger) CounterRepository {
+	return &counterRepo{coll: db.Collection("counters"), logger: logger}
+}
+
+// Next increments the named counter and returns its new value; the first
+// call for a name returns 1. Called inside a transaction, the increment is
+// rolled back with it, and concurrent transactions drawing from the same
+// counter conflict and are retried, so values are handed out in commit order
+// without gaps.
+func (r *counterRepo) Next(ctx context.Context, name string) (int64, error) {
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var doc struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := r.coll.FindOneAndUpdate(ctx,
+		bson.M{"_id": name},
+		bson.M{"$inc": bson.M{"seq": int64(1)}},
+		opts,
+	).Decode(&doc)
+	if err != nil {
+		return 0, err
+	}
+	return doc.Seq, nil
+}
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/database"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+type couponRepo struct {
+	coll        *mongo.Collection
+	redemptions *mongo.Collection
+	logger      *zap.Logger
+}
+
+func NewCouponRepository(db *database.MongoDB, logger *zap.Logger) CouponRepository {
+	c := db.Collection("coupons")
+	red := db.Collection("coupon_redemptions")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mod := mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	if _, err := c.Indexes().CreateOne(ctx, mod); err != nil {
+		logger.Warn("could not create coupon indexes", zap.Error(err))
+	}
+	mods := []mongo.IndexModel{
+		// per-user limits count redemptions by coupon and user
+		{Keys: bson.D{{Key: "coupon_id", Value: 1}, {Key: "user_id", Value: 1}}},
+		// an order redeems at most one coupon
+		{Keys: bson.D{{Key: "order_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+	}
+	if _, err := red.Indexes().CreateMany(ctx, mods); err != nil {
+		logger.Warn("could not create coupon redemption indexes", zap.Error(err))
+	}
+	return &couponRepo{coll: c, redemptions: red, logger: logger}
+}
+
+func (r *couponRepo) Create(ctx context.Context, c *domain.Coupon) error {
+	now := time.Now().UTC()
+	c.CreatedAt = now
+	c.UpdatedAt = now
+	c.Uses = 0
+	res, err := r.coll.InsertOne(ctx, c)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	oid := res.InsertedID.(bson.ObjectID)
+	c.ID = oid.Hex()
+	return nil
+}
+
+func (r *couponRepo) GetByID(ctx context.Context, id string) (*domain.Coupon, error) {
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	return r.findOne(ctx, bson.M{"_id": oid})
+}
+
+func (r *couponRepo) GetByCode(ctx context.Context, code string) (*domain.Coupon, error) {
+	return r.findOne(ctx, bson.M{"code": code})
+}
+
+func (r *couponRepo) findOne(ctx context.Context, filter bson.M) (*domain.Coupon, error) {
+	var c domain.Coupon
+	if err := r.coll.FindOne(ctx, filter).Decode(&c); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Update replaces the coupon's settings. The usage counter and creation time
+// are left alone.
+func (r *couponRepo) Update(ctx context.Context, c *domain.Coupon) error {
+	oid, err := bson.ObjectIDFromHex(c.ID)
+	if err != nil {
+		return ErrNotFound
+	}
+	c.UpdatedAt = time.Now().UTC()
+	res, err := r.coll.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": bson.M{
+		"code":              c.Code,
+		"type":              c.Type,
+		"percent_bps":       c.PercentBPS,
+		"amount_off":        c.AmountOff,
+		"min_order":         c.MinOrder,
+		"max_uses":          c.MaxUses,
+		"max_uses_per_user": c.MaxUsesPerUser,
+		"starts_at":         c.StartsAt,
+		"ends_at":           c.EndsAt,
+		"product_ids":       c.ProductIDs,
+		"active":            c.Active,
+		"updated_at":        c.UpdatedAt,
+	}})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *couponRepo) Delete(ctx context.Context, id string) error {
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+	res, err := r.coll.DeleteOne(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *couponRepo) List(ctx context.Context, limit, page int) ([]*domain.Coupon, int64, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if page <= 0 {
+		page = 1
+	}
+	skip := int64((page - 1) * limit)
+	findOptions := options.Find().SetLimit(int64(limit)).SetSkip(skip).SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cur, err := r.coll.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cur.Close(ctx)
+	out := []*domain.Coupon{}
+	for cur.Next(ctx) {
+		var c domain.Coupon
+		if err := cur.Decode(&c); err != nil {
+			return nil, 0, err
+		}
+		out = append(out, &c)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, 0, err
+	}
+	total, err := r.coll.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+func (r *couponRepo) CountRedemptions(ctx context.Context, couponID, userID string) (int64, error) {
+	return r.redemptions.CountDocuments(ctx, bson.M{"coupon_id": couponID, "user_id": userID})
+}
+
+// Redeem counts one use of the coupon and records the redemption. The
+// counter only moves while it is below the coupon's global limit; otherwise
+// ErrLimitReached is returned. Run it in a transaction together with the
+// per-user check so concurrent redemptions conflict instead of both passing.
+func (r *couponRepo) Redeem(ctx context.Context, c *domain.Coupon, red *domain.CouponRedemption) error {
+	oid, err := bson.ObjectIDFromHex(c.ID)
+	if err != nil {
+		return ErrNotFound
+	}
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": oid, "$or": bson.A{
+			bson.M{"max_uses": 0},
+			bson.M{"$expr": bson.M{"$lt": bson.A{"$uses", "$max_uses"}}},
+		}},
+		bson.M{"$inc": bson.M{"uses": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrLimitReached
+	}
+	red.CreatedAt = time.Now().UTC()
+	ins, err := r.redemptions.InsertOne(ctx, red)
+	if err != nil {
+		return err
+	}
+	red.ID = ins.InsertedID.(bson.ObjectID).Hex()
+	return nil
+}
+
+// Release gives back the coupon use recorded for an order, if there is one.
+func (r *couponRepo) Release(ctx context.Context, orderID string) error {
+	var red domain.CouponRedemption
+	err := r.redemptions.FindOneAndDelete(ctx, bson.M{"order_id": orderID}).Decode(&red)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	oid, err := bson.ObjectIDFromHex(red.CouponID)
+	if err != nil {
+		return nil
+	}
+	// the coupon may have been deleted since; nothing to give back then
+	_, err = r.coll.UpdateOne(ctx, bson.M{"_id": oid, "uses": bson.M{"$gt": 0}}, bson.M{"$inc": bson.M{"uses": -1}})
+	return err
+}
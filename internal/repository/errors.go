@@ -0,0 +1,20 @@
+package repository
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when a lookup matches no document.
+	ErrNotFound = errors.New("not found")
+	// ErrInsufficientStock is returned when a stock decrement would take a
+	// product below zero.
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrInvalidCursor is returned when a pagination cursor cannot be
+	// decoded.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrDuplicate is returned when an insert or update would break a
+	// unique index.
+	ErrDuplicate = errors.New("duplicate key")
+	// ErrLimitReached is returned when a counter update would exceed its
+	// configured maximum.
+	ErrLimitReached = errors.New("limit reached")
+)
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/database"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+type idempotencyRepo struct {
+	coll   *mongo.Collection
+	logger *zap.Logger
+}
+
+// NewIdempotencyRepository stores idempotency records, letting MongoDB
+// expire them ttl after they were created.
+func NewIdempotencyRepository(db *database.MongoDB, ttl time.Duration, logger *zap.Logger) IdempotencyRepository {
+	c := db.Collection("idempotency_keys")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mods := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(ttl.Seconds())),
+		},
+	}
+	if _, err := c.Indexes().CreateMany(ctx, mods); err != nil {
+		logger.Warn("could not create idempotency indexes", zap.Error(err))
+	}
+	return &idempotencyRepo{coll: c, logger: logger}
+}
+
+// reserveAttempts bounds how often Reserve retries when the record it
+// collided with disappears before it can be read.
+const reserveAttempts = 3
+
+// Reserve claims (userID, key) for a new request. If the pair was already
+// claimed, the existing record is returned and created is false.
+func (r *idempotencyRepo) Reserve(ctx context.Context, userID, key, requestHash string) (*domain.IdempotencyRecord, bool, error) {
+	for attempt := 1; ; attempt++ {
+		rec := &domain.IdempotencyRecord{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: requestHash,
+			CreatedAt:   time.Now().UTC(),
+		}
+		_, err := r.coll.InsertOne(ctx, rec)
+		if err == nil {
+			return rec, true, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, false, err
+		}
+		var existing domain.IdempotencyRecord
+		err = r.coll.FindOne(ctx, bson.M{"user_id": userID, "key": key}).Decode(&existing)
+		if err == nil {
+			return &existing, false, nil
+		}
+		// the record expired or was released between the insert and the
+		// lookup, so the key is free again
+		if !errors.Is(err, mongo.ErrNoDocuments) || attempt == reserveAttempts {
+			return nil, false, err
+		}
+	}
+}
+
+// Complete stores the response for a reserved key.
+func (r *idempotencyRepo) Complete(ctx context.Context, userID, key string, status int, header map[string][]string, body []byte) error {
+	_, err := r.coll.UpdateOne(ctx,
+		bson.M{"user_id": userID, "key": key},
+		bson.M{"$set": bson.M{
+			"completed":   true,
+			"status_code": status,
+			"header":      header,
+			"body":        body,
+		}},
+	)
+	return err
+}
+
+// Release drops a reservation so the key can be used again.
+func (r *idempotencyRepo) Release(ctx context.Context, userID, key string) error {
+	_, err := r.coll.DeleteOne(ctx, bson.M{"user_id": userID, "key": key, "completed": false})
+	return err
+}
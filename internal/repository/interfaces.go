@@ -2,16 +2,24 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/rseigha/goecomapi/internal/domain"
 )
 
+// Transactor runs fn inside a database transaction. Repository calls made
+// with the context handed to fn are part of that transaction.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type UserRepository interface {
 	Create(ctx context.Context, u *domain.User) error
 	GetByID(ctx context.Context, id string) (*domain.User, error)
 	GetByEmail(ctx context.Context, email string) (*domain.User, error)
 	Update(ctx context.Context, u *domain.User) error
 	Delete(ctx context.Context, id string) error
+	SaveAddresses(ctx context.Context, userID string, addrs []domain.Address) error
 }
 
 type ProductRepository interface {
@@ -20,10 +28,105 @@ type ProductRepository interface {
 	Update(ctx context.Context, p *domain.Product) error
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, limit, page int) ([]*domain.Product, int64, error)
+	DecrementStock(ctx context.Context, id string, qty int) error
+	IncrementStock(ctx context.Context, id string, qty int) error
 }
 
 type OrderRepository interface {
 	Create(ctx context.Context, o *domain.Order) error
 	GetByID(ctx context.Context, id string) (*domain.Order, error)
-	GetByUserID(ctx context.Context, userID string) ([]*domain.Order, error)
+	GetByNumber(ctx context.Context, number string) (*domain.Order, error)
+	GetByUserID(ctx context.Context, userID string, status domain.OrderStatus, limit int, cursor string) (*domain.OrderPage, error)
+	UpdateStatus(ctx context.Context, id string, change domain.StatusChange) error
+	Cancel(ctx context.Context, id string, change domain.StatusChange) error
+	AddRefund(ctx context.Context, id string, lineQty map[int]int, amount domain.Money) error
+	AddShipment(ctx context.Context, o *domain.Order, s domain.Shipment, lineQty map[int]int, change *domain.StatusChange) error
+	UpdateShipment(ctx context.Context, orderID string, s domain.Shipment) error
+	AddReturned(ctx context.Context, o *domain.Order, lineQty map[int]int) error
+	Search(ctx context.Context, f domain.OrderFilter) ([]*domain.Order, int64, error)
+	ExportPage(ctx context.Context, f domain.OrderFilter, cursor string, limit int) (*domain.OrderPage, error)
+}
+
+type CartRepository interface {
+	Get(ctx context.Context, owner domain.CartOwner) (*domain.Cart, error)
+	Save(ctx context.Context, c *domain.Cart) error
+	Delete(ctx context.Context, owner domain.CartOwner) error
+}
+
+type PaymentRepository interface {
+	Create(ctx context.Context, p *domain.Payment) error
+	GetByProviderRef(ctx context.Context, provider, ref string) (*domain.Payment, error)
+	ListByOrder(ctx context.Context, orderID string) ([]*domain.Payment, error)
+	UpdateStatus(ctx context.Context, id string, from, to domain.PaymentStatus, reason string) error
+	AddRefund(ctx context.Context, id string, amount domain.Money) error
+}
+
+type RefundRepository interface {
+	Create(ctx context.Context, r *domain.Refund) error
+	Settle(ctx context.Context, id string, status domain.RefundStatus, providerRef, failureReason string) error
+	ListByOrder(ctx context.Context, orderID string) ([]*domain.Refund, error)
+}
+
+type CouponRepository interface {
+	Create(ctx context.Context, c *domain.Coupon) error
+	GetByID(ctx context.Context, id string) (*domain.Coupon, error)
+	GetByCode(ctx context.Context, code string) (*domain.Coupon, error)
+	Update(ctx context.Context, c *domain.Coupon) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, limit, page int) ([]*domain.Coupon, int64, error)
+	CountRedemptions(ctx context.Context, couponID, userID string) (int64, error)
+	Redeem(ctx context.Context, c *domain.Coupon, r *domain.CouponRedemption) error
+	Release(ctx context.Context, orderID string) error
+}
+
+type PromotionRepository interface {
+	Create(ctx context.Context, p *domain.Promotion) error
+	GetByID(ctx context.Context, id string) (*domain.Promotion, error)
+	Update(ctx context.Context, p *domain.Promotion) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, limit, page int) ([]*domain.Promotion, int64, error)
+	ListActive(ctx context.Context, at time.Time) ([]*domain.Promotion, error)
+}
+
+type ShippingMethodRepository interface {
+	Create(ctx context.Context, m *domain.ShippingMethod) error
+	GetByID(ctx context.Context, id string) (*domain.ShippingMethod, error)
+	GetByCode(ctx context.Context, code string) (*domain.ShippingMethod, error)
+	Update(ctx context.Context, m *domain.ShippingMethod) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, activeOnly bool) ([]*domain.ShippingMethod, error)
+}
+
+type ReturnRepository interface {
+	Create(ctx context.Context, rt *domain.Return) error
+	GetByID(ctx context.Context, id string) (*domain.Return, error)
+	ListByUser(ctx context.Context, userID string) ([]*domain.Return, error)
+	ListByOrder(ctx context.Context, orderID string) ([]*domain.Return, error)
+	List(ctx context.Context, status domain.ReturnStatus, limit, page int) ([]*domain.Return, int64, error)
+	UpdateStatus(ctx context.Context, rt *domain.Return, change domain.ReturnStatusChange) error
+	SetRefund(ctx context.Context, id, refundID string) error
+}
+
+type InvoiceRepository interface {
+	Create(ctx context.Context, inv *domain.Invoice) error
+	GetByOrder(ctx context.Context, orderID string) (*domain.Invoice, error)
+}
+
+type ReservationRepository interface {
+	Create(ctx context.Context, rv *domain.Reservation) error
+	GetByOrder(ctx context.Context, orderID string) (*domain.Reservation, error)
+	Close(ctx context.Context, orderID string, status domain.ReservationStatus, reason string) error
+	ListExpired(ctx context.Context, now time.Time, after *domain.Reservation, limit int) ([]*domain.Reservation, error)
+	List(ctx context.Context, status domain.ReservationStatus, productID string, limit, page int) ([]*domain.Reservation, int64, error)
+	Reserved(ctx context.Context, productID string) ([]domain.StockLevel, error)
+}
+
+type CounterRepository interface {
+	Next(ctx context.Context, name string) (int64, error)
+}
+
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, userID, key, requestHash string) (*domain.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, userID, key string, status int, header map[string][]string, body []byte) error
+	Release(ctx context.Context, userID, key string) error
 }
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/database"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+type invoiceRepo struct {
+	coll   *mongo.Collection
+	logger *zap.Logger
+}
+
+func NewInvoiceRepository(db *database.MongoDB, logger *zap.Logger) InvoiceRepository {
+	c := db.Collection("invoices")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// one invoice per order, one order per invoice number
+	mods := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "order_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "number", Value: 1}}, Options: options.Index().SetUnique(true)},
+	}
+	if _, err := c.Indexes().CreateMany(ctx, mods); err != nil {
+		logger.Warn("could not create invoice indexes", zap.Error(err))
+	}
+	return &invoiceRepo{coll: c, logger: logger}
+}
+
+// Create stores a new invoice. It returns ErrDuplicate if the order already
+// has one.
+func (r *invoiceRepo) Create(ctx context.Context, inv *domain.Invoice) error {
+	res, err := r.coll.InsertOne(ctx, inv)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	oid := res.InsertedID.(bson.ObjectID)
+	inv.ID = oid.Hex()
+	return nil
+}
+
+func (r *invoiceRepo) GetByOrder(ctx context.Context, orderID string) (*domain.Invoice, error) {
+	var inv domain.Invoice
+	if err := r.coll.FindOne(ctx, bson.M{"order_id": orderID}).Decode(&inv); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &inv, nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/rseigha/goecomapi/internal/database"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+)
+
+// MigrateLegacyMoney rewrites product prices and order amounts that are still
+// stored as plain floating point numbers into Money documents, using
+// domain.DefaultCurrency. Documents already migrated are left alone, so it is
+// safe to run more than once.
+func MigrateLegacyMoney(ctx context.Context, db *database.MongoDB, logger *zap.Logger) (products, orders int64, err error) {
+	legacy := bson.M{"$type": "number"}
+
+	pc := db.Collection("products")
+	cur, err := pc.Find(ctx, bson.M{"price": legacy})
+	if err != nil {
+		return 0, 0, err
+	}
+	for cur.Next(ctx) {
+		// decoding converts the legacy number through Money.UnmarshalBSONValue
+		var p domain.Product
+		if err := cur.Decode(&p); err != nil {
+			cur.Close(ctx)
+			return products, orders, err
+		}
+		if _, err := pc.UpdateOne(ctx, bson.M{"_id": cur.Current.Lookup("_id")}, bson.M{"$set": bson.M{"price": p.Price}}); err != nil {
+			cur.Close(ctx)
+			return products, orders, err
+		}
+		products++
+	}
+	cur.Close(ctx)
+	if err := cur.Err(); err != nil {
+		return products, orders, err
+	}
+	logger.Info("migrated product prices", zap.Int64("count", products))
+
+	oc := db.Collection("orders")
+	cur, err = oc.Find(ctx, bson.M{"$or": bson.A{bson.M{"total": legacy}, bson.M{"items.price": legacy}}})
+	if err != nil {
+		return products, orders, err
+	}
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		var o domain.Order
+		if err := cur.Decode(&o); err != nil {
+			return products, orders, err
+		}
+		update := bson.M{"$set": bson.M{"total": o.Total, "items": o.Items}}
+		if _, err := oc.UpdateOne(ctx, bson.M{"_id": cur.Current.Lookup("_id")}, update); err != nil {
+			return products, orders, err
+		}
+		orders++
+	}
+	if err := cur.Err(); err != nil {
+		return products, orders, err
+	}
+	logger.Info("migrated order amounts", zap.Int64("count", orders))
+	return products, orders, nil
+}
@@ -2,12 +2,17 @@ package repository
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/rseigha/goecomapi/internal/database"
 	"github.com/rseigha/goecomapi/internal/domain"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"go.uber.org/zap"
 )
 
@@ -18,13 +23,36 @@ type orderRepo struct {
 
 func NewOrderRepository(db *database.MongoDB, logger *zap.Logger) OrderRepository {
 	c := db.Collection("orders")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// indexes backing order history pages and the admin order search
+	mods := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "items.product_id", Value: 1}}},
+		{Keys: bson.D{{Key: "total.currency", Value: 1}, {Key: "total.amount", Value: 1}}},
+		// orders placed before numbering was introduced have no number
+		{Keys: bson.D{{Key: "number", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+	}
+	if _, err := c.Indexes().CreateMany(ctx, mods); err != nil {
+		logger.Warn("could not create order indexes", zap.Error(err))
+	}
 	return &orderRepo{coll: c, logger: logger}
 }
 
+// Create inserts o. Timestamps the caller already set are kept, since the
+// order number and history are derived from them.
 func (r *orderRepo) Create(ctx context.Context, o *domain.Order) error {
 	now := time.Now().UTC()
-	o.CreatedAt = now
-	o.UpdatedAt = now
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = now
+	}
+	if o.UpdatedAt.IsZero() {
+		o.UpdatedAt = o.CreatedAt
+	}
 	res, err := r.coll.InsertOne(ctx, o)
 	if err != nil {
 		return err
@@ -37,30 +65,437 @@ func (r *orderRepo) Create(ctx context.Context, o *domain.Order) error {
 func (r *orderRepo) GetByID(ctx context.Context, id string) (*domain.Order, error) {
 	oid, err := bson.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return nil, ErrNotFound
 	}
 	var o domain.Order
 	if err := r.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&o); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
 		return nil, err
 	}
 	o.ID = oid.Hex()
 	return &o, nil
 }
 
-func (r *orderRepo) GetByUserID(ctx context.Context, userID string) ([]*domain.Order, error) {
+func (r *orderRepo) GetByNumber(ctx context.Context, number string) (*domain.Order, error) {
+	var o domain.Order
+	if err := r.coll.FindOne(ctx, bson.M{"number": number}).Decode(&o); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &o, nil
+}
+
+// UpdateStatus moves the order from change.From to change.To and appends
+// change to its history. The update only applies while the order is still in
+// the From status; otherwise ErrNotFound is returned.
+func (r *orderRepo) UpdateStatus(ctx context.Context, id string, change domain.StatusChange) error {
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": oid, "status": change.From},
+		bson.M{
+			"$set":  bson.M{"status": change.To, "updated_at": change.At},
+			"$push": bson.M{"history": change},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Cancel marks the order as canceled and records who canceled it and why.
+// Like UpdateStatus it only applies while the order is still in the
+// change.From status.
+func (r *orderRepo) Cancel(ctx context.Context, id string, change domain.StatusChange) error {
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": oid, "status": change.From},
+		bson.M{
+			"$set": bson.M{
+				"status":        domain.OrderCanceled,
+				"canceled_by":   change.ActorID,
+				"cancel_reason": change.Reason,
+				"canceled_at":   change.At,
+				"updated_at":    change.At,
+			},
+			"$push": bson.M{"history": change},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AddRefund adds amount to the order's refunded total and lineQty[i] units to
+// the refunded quantity of line i. Negative values undo an earlier call.
+func (r *orderRepo) AddRefund(ctx context.Context, id string, lineQty map[int]int, amount domain.Money) error {
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+	inc := bson.M{"refunded.amount": amount.Amount}
+	for i, qty := range lineQty {
+		inc[fmt.Sprintf("items.%d.refunded_quantity", i)] = qty
+	}
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": oid},
+		bson.M{
+			"$inc": inc,
+			"$set": bson.M{"refunded.currency": amount.Currency, "updated_at": time.Now().UTC()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AddShipment records a shipment on o and adds lineQty[i] units to the shipped
+// quantity of line i, moving the order to change.To when change is set. The
+// update only applies while o's status and shipped quantities are unchanged,
+// so concurrent shipments cannot send the same units twice; ErrNotFound is
+// returned otherwise.
+func (r *orderRepo) AddShipment(ctx context.Context, o *domain.Order, s domain.Shipment, lineQty map[int]int, change *domain.StatusChange) error {
+	oid, err := bson.ObjectIDFromHex(o.ID)
+	if err != nil {
+		return ErrNotFound
+	}
+	filter := bson.M{"_id": oid, "status": o.Status}
+	inc := bson.M{}
+	for i, qty := range lineQty {
+		key := fmt.Sprintf("items.%d.shipped_quantity", i)
+		if o.Items[i].ShippedQuantity == 0 {
+			filter[key] = bson.M{"$in": bson.A{0, nil}}
+		} else {
+			filter[key] = o.Items[i].ShippedQuantity
+		}
+		inc[key] = qty
+	}
+	set := bson.M{"updated_at": s.CreatedAt}
+	push := bson.M{"shipments": s}
+	if change != nil {
+		set["status"] = change.To
+		push["history"] = change
+	}
+	res, err := r.coll.UpdateOne(ctx, filter, bson.M{"$inc": inc, "$set": set, "$push": push})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateShipment replaces the carrier and tracking details of one of the
+// order's shipments.
+func (r *orderRepo) UpdateShipment(ctx context.Context, orderID string, s domain.Shipment) error {
+	oid, err := bson.ObjectIDFromHex(orderID)
+	if err != nil {
+		return ErrNotFound
+	}
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": oid, "shipments.id": s.ID},
+		bson.M{"$set": bson.M{
+			"shipments.$.carrier":         s.Carrier,
+			"shipments.$.tracking_number": s.TrackingNumber,
+			"shipments.$.tracking_url":    s.TrackingURL,
+			"shipments.$.shipped_at":      s.ShippedAt,
+			"shipments.$.updated_at":      s.UpdatedAt,
+			"updated_at":                  s.UpdatedAt,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AddReturned adds lineQty[i] units to the returned quantity of line i;
+// negative values release units from rejected or canceled returns. Like
+// AddShipment it only applies while o's returned quantities are unchanged.
+func (r *orderRepo) AddReturned(ctx context.Context, o *domain.Order, lineQty map[int]int) error {
+	oid, err := bson.ObjectIDFromHex(o.ID)
+	if err != nil {
+		return ErrNotFound
+	}
+	filter := bson.M{"_id": oid}
+	inc := bson.M{}
+	for i, qty := range lineQty {
+		key := fmt.Sprintf("items.%d.returned_quantity", i)
+		if o.Items[i].ReturnedQuantity == 0 {
+			filter[key] = bson.M{"$in": bson.A{0, nil}}
+		} else {
+			filter[key] = o.Items[i].ReturnedQuantity
+		}
+		inc[key] = qty
+	}
+	res, err := r.coll.UpdateOne(ctx, filter, bson.M{
+		"$inc": inc,
+		"$set": bson.M{"updated_at": time.Now().UTC()},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetByUserID returns the user's orders newest first, limit at a time. An
+// empty cursor starts at the most recent order; pass the returned NextCursor
+// to fetch the following page.
+func (r *orderRepo) GetByUserID(ctx context.Context, userID string, status domain.OrderStatus, limit int, cursor string) (*domain.OrderPage, error) {
+	if limit <= 0 {
+		limit = 20
+	}
 	// userID is stored as string (ObjectID hex)
-	cur, err := r.coll.Find(ctx, bson.M{"user_id": userID})
+	filter := bson.M{"user_id": userID}
+	if status != "" {
+		filter["status"] = status
+	}
+	if cursor != "" {
+		createdAt, oid, err := decodeOrderCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$lt": createdAt}},
+			bson.M{"created_at": createdAt, "_id": bson.M{"$lt": oid}},
+		}
+	}
+	// fetch one extra document to learn whether another page exists
+	findOptions := options.Find().
+		SetLimit(int64(limit + 1)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	cur, err := r.coll.Find(ctx, filter, findOptions)
 	if err != nil {
 		return nil, err
 	}
 	defer cur.Close(ctx)
-	var out []*domain.Order
+	page := &domain.OrderPage{Items: []*domain.Order{}}
 	for cur.Next(ctx) {
 		var o domain.Order
 		if err := cur.Decode(&o); err != nil {
 			return nil, err
 		}
+		page.Items = append(page.Items, &o)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor = encodeOrderCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+// encodeOrderCursor packs the sort key of the last order on a page into an
+// opaque token.
+func encodeOrderCursor(createdAt time.Time, id string) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeOrderCursor(cursor string) (time.Time, bson.ObjectID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, bson.ObjectID{}, ErrInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, bson.ObjectID{}, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, bson.ObjectID{}, ErrInvalidCursor
+	}
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return time.Time{}, bson.ObjectID{}, ErrInvalidCursor
+	}
+	return createdAt, oid, nil
+}
+
+// Search returns one page of orders matching f together with the total
+// number of matches.
+func (r *orderRepo) Search(ctx context.Context, f domain.OrderFilter) ([]*domain.Order, int64, error) {
+	limit, page := f.Limit, f.Page
+	if limit <= 0 {
+		limit = 20
+	}
+	if page <= 0 {
+		page = 1
+	}
+	filter := orderSearchFilter(f)
+	skip := int64((page - 1) * limit)
+	findOptions := options.Find().SetLimit(int64(limit)).SetSkip(skip).SetSort(orderSearchSort(f.Sort))
+	cur, err := r.coll.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cur.Close(ctx)
+	out := []*domain.Order{}
+	for cur.Next(ctx) {
+		var o domain.Order
+		if err := cur.Decode(&o); err != nil {
+			return nil, 0, err
+		}
 		out = append(out, &o)
 	}
-	return out, nil
+	if err := cur.Err(); err != nil {
+		return nil, 0, err
+	}
+	total, err := r.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+// ExportPage returns up to limit orders matching f, oldest first, starting
+// after cursor. Sort, Limit and Page in f are ignored. Like GetByUserID it
+// pages on (created_at, _id), so walking a large range never skips or
+// repeats orders and needs no server-side cursor kept open between pages.
+func (r *orderRepo) ExportPage(ctx context.Context, f domain.OrderFilter, cursor string, limit int) (*domain.OrderPage, error) {
+	if limit <= 0 {
+		limit = 500
+	}
+	filter := orderSearchFilter(f)
+	if cursor != "" {
+		createdAt, oid, err := decodeOrderCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$gt": createdAt}},
+			bson.M{"created_at": createdAt, "_id": bson.M{"$gt": oid}},
+		}
+	}
+	findOptions := options.Find().
+		SetLimit(int64(limit + 1)).
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := r.coll.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	page := &domain.OrderPage{Items: []*domain.Order{}}
+	for cur.Next(ctx) {
+		var o domain.Order
+		if err := cur.Decode(&o); err != nil {
+			return nil, err
+		}
+		page.Items = append(page.Items, &o)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor = encodeOrderCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+// EachOrder calls fn for every order matching f, oldest first, reading
+// pageSize orders at a time through r.ExportPage. It stops at the first
+// error from r or fn.
+func EachOrder(ctx context.Context, r OrderRepository, f domain.OrderFilter, pageSize int, fn func(*domain.Order) error) error {
+	cursor := ""
+	for {
+		page, err := r.ExportPage(ctx, f, cursor, pageSize)
+		if err != nil {
+			return err
+		}
+		for _, o := range page.Items {
+			if err := fn(o); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+func orderSearchFilter(f domain.OrderFilter) bson.M {
+	filter := bson.M{}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	if f.UserID != "" {
+		filter["user_id"] = f.UserID
+	}
+	if f.ProductID != "" {
+		filter["items.product_id"] = f.ProductID
+	}
+	created := bson.M{}
+	if f.CreatedFrom != nil {
+		created["$gte"] = *f.CreatedFrom
+	}
+	if f.CreatedTo != nil {
+		created["$lt"] = *f.CreatedTo
+	}
+	if len(created) > 0 {
+		filter["created_at"] = created
+	}
+	if f.Currency != "" {
+		filter["total.currency"] = f.Currency
+	}
+	total := bson.M{}
+	if f.MinTotal != nil {
+		total["$gte"] = *f.MinTotal
+	}
+	if f.MaxTotal != nil {
+		total["$lte"] = *f.MaxTotal
+	}
+	if len(total) > 0 {
+		filter["total.amount"] = total
+	}
+	return filter
+}
+
+func orderSearchSort(sort string) bson.D {
+	if sort == "" {
+		sort = "-created_at"
+	}
+	dir := 1
+	if strings.HasPrefix(sort, "-") {
+		dir = -1
+		sort = sort[1:]
+	}
+	if sort == "total" {
+		sort = "total.amount"
+	}
+	// _id breaks ties so pages are stable
+	return bson.D{{Key: sort, Value: dir}, {Key: "_id", Value: dir}}
 }
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestOrderCursorRoundTrip(t *testing.T) {
+	id := bson.NewObjectID()
+	tests := []time.Time{
+		time.Date(2026, 3, 14, 15, 9, 26, 535897932, time.UTC),
+		time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		// other zones come back as the same instant in UTC
+		time.Date(2026, 7, 4, 12, 0, 0, 1000, time.FixedZone("EST", -5*3600)),
+	}
+	for _, createdAt := range tests {
+		cursor := encodeOrderCursor(createdAt, id.Hex())
+		gotAt, gotID, err := decodeOrderCursor(cursor)
+		if err != nil {
+			t.Fatalf("decodeOrderCursor(%q) error = %v", cursor, err)
+		}
+		if !gotAt.Equal(createdAt) || gotID != id {
+			t.Errorf("round trip of %v, %s = %v, %s", createdAt, id.Hex(), gotAt, gotID.Hex())
+		}
+	}
+}
+
+func TestDecodeOrderCursorRejectsMalformed(t *testing.T) {
+	enc := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	id := bson.NewObjectID().Hex()
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "not a cursor!"},
+		{"truncated", encodeOrderCursor(time.Now(), id)[:40]},
+		{"no separator", enc("2026-01-01T00:00:00Z" + id)},
+		{"bad time", enc("yesterday|" + id)},
+		{"bad id", enc("2026-01-01T00:00:00Z|123")},
+		{"empty parts", enc("|")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeOrderCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeOrderCursor(%q) error = %v, want ErrInvalidCursor", tt.cursor, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/database"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+type paymentRepo struct {
+	coll   *mongo.Collection
+	logger *zap.Logger
+}
+
+func NewPaymentRepository(db *database.MongoDB, logger *zap.Logger) PaymentRepository {
+	c := db.Collection("payments")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mods := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "provider_ref", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "order_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	}
+	if _, err := c.Indexes().CreateMany(ctx, mods); err != nil {
+		logger.Warn("could not create payment indexes", zap.Error(err))
+	}
+	return &paymentRepo{coll: c, logger: logger}
+}
+
+func (r *paymentRepo) Create(ctx context.Context, p *domain.Payment) error {
+	now := time.Now().UTC()
+	p.CreatedAt = now
+	p.UpdatedAt = now
+	res, err := r.coll.InsertOne(ctx, p)
+	if err != nil {
+		return err
+	}
+	oid := res.InsertedID.(bson.ObjectID)
+	p.ID = oid.Hex()
+	return nil
+}
+
+func (r *paymentRepo) GetByProviderRef(ctx context.Context, provider, ref string) (*domain.Payment, error) {
+	var p domain.Payment
+	if err := r.coll.FindOne(ctx, bson.M{"provider": provider, "provider_ref": ref}).Decode(&p); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *paymentRepo) ListByOrder(ctx context.Context, orderID string) ([]*domain.Payment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cur, err := r.coll.Find(ctx, bson.M{"order_id": orderID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	out := []*domain.Payment{}
+	for cur.Next(ctx) {
+		var p domain.Payment
+		if err := cur.Decode(&p); err != nil {
+			return nil, err
+		}
+		out = append(out, &p)
+	}
+	return out, cur.Err()
+}
+
+// AddRefund adds amount to the payment's refunded total. A negative amount
+// undoes an earlier call.
+func (r *paymentRepo) AddRefund(ctx context.Context, id string, amount domain.Money) error {
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": oid},
+		bson.M{
+			"$inc": bson.M{"refunded.amount": amount.Amount},
+			"$set": bson.M{"refunded.currency": amount.Currency, "updated_at": time.Now().UTC()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateStatus moves a payment from one status to another, recording an
+// optional failure reason. It returns ErrNotFound if the payment is no longer
+// in the from status.
+func (r *paymentRepo) UpdateStatus(ctx context.Context, id string, from, to domain.PaymentStatus, reason string) error {
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+	set := bson.M{"status": to, "updated_at": time.Now().UTC()}
+	if reason != "" {
+		set["failure_reason"] = reason
+	}
+	res, err := r.coll.UpdateOne(ctx, bson.M{"_id": oid, "status": from}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/rseigha/goecomapi/internal/database"
@@ -49,23 +50,37 @@ func (r *productRepo) Create(ctx context.Context, p *domain.Product) error {
 func (r *productRepo) GetByID(ctx context.Context, id string) (*domain.Product, error) {
 	oid, err := bson.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return nil, ErrNotFound
 	}
 	var p domain.Product
 	if err := r.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&p); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
 		return nil, err
 	}
 	p.ID = oid.Hex()
 	return &p, nil
 }
 
+// Update replaces the product's catalog details. Stock is left alone: orders
+// and reservations change it concurrently, so it only moves through the
+// increment and decrement operations.
 func (r *productRepo) Update(ctx context.Context, p *domain.Product) error {
 	oid, err := bson.ObjectIDFromHex(p.ID)
 	if err != nil {
 		return err
 	}
 	p.UpdatedAt = time.Now().UTC()
-	_, err = r.coll.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": p})
+	_, err = r.coll.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": bson.M{
+		"name":         p.Name,
+		"description":  p.Description,
+		"sku":          p.SKU,
+		"price":        p.Price,
+		"tax_class":    p.TaxClass,
+		"weight_grams": p.WeightGrams,
+		"updated_at":   p.UpdatedAt,
+	}})
 	return err
 }
 
@@ -78,6 +93,46 @@ func (r *productRepo) Delete(ctx context.Context, id string) error {
 	return err
 }
 
+// DecrementStock atomically removes qty units from the product's stock. The
+// update only matches while enough stock remains, so concurrent callers can
+// never drive it negative.
+func (r *productRepo) DecrementStock(ctx context.Context, id string, qty int) error {
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": oid, "stock": bson.M{"$gte": qty}},
+		bson.M{"$inc": bson.M{"stock": -qty}, "$set": bson.M{"updated_at": time.Now().UTC()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// IncrementStock returns qty units to the product's stock.
+func (r *productRepo) IncrementStock(ctx context.Context, id string, qty int) error {
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": oid},
+		bson.M{"$inc": bson.M{"stock": qty}, "$set": bson.M{"updated_at": time.Now().UTC()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 func (r *productRepo) List(ctx context.Context, limit, page int) ([]*domain.Product, int64, error) {
 	if limit <= 0 {
 		limit = 10
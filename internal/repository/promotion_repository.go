@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/database"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+type promotionRepo struct {
+	coll   *mongo.Collection
+	logger *zap.Logger
+}
+
+func NewPromotionRepository(db *database.MongoDB, logger *zap.Logger) PromotionRepository {
+	c := db.Collection("promotions")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mod := mongo.IndexModel{Keys: bson.D{{Key: "active", Value: 1}, {Key: "priority", Value: -1}}}
+	if _, err := c.Indexes().CreateOne(ctx, mod); err != nil {
+		logger.Warn("could not create promotion indexes", zap.Error(err))
+	}
+	return &promotionRepo{coll: c, logger: logger}
+}
+
+func (r *promotionRepo) Create(ctx context.Context, p *domain.Promotion) error {
+	now := time.Now().UTC()
+	p.CreatedAt = now
+	p.UpdatedAt = now
+	res, err := r.coll.InsertOne(ctx, p)
+	if err != nil {
+		return err
+	}
+	oid := res.InsertedID.(bson.ObjectID)
+	p.ID = oid.Hex()
+	return nil
+}
+
+func (r *promotionRepo) GetByID(ctx context.Context, id string) (*domain.Promotion, error) {
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	var p domain.Promotion
+	if err := r.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&p); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Update replaces the promotion, keeping its creation time.
+func (r *promotionRepo) Update(ctx context.Context, p *domain.Promotion) error {
+	oid, err := bson.ObjectIDFromHex(p.ID)
+	if err != nil {
+		return ErrNotFound
+	}
+	existing, err := r.GetByID(ctx, p.ID)
+	if err != nil {
+		return err
+	}
+	p.CreatedAt = existing.CreatedAt
+	p.UpdatedAt = time.Now().UTC()
+	// the stored _id is an ObjectID; leave it out of the replacement
+	id := p.ID
+	p.ID = ""
+	res, err := r.coll.ReplaceOne(ctx, bson.M{"_id": oid}, p)
+	p.ID = id
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *promotionRepo) Delete(ctx context.Context, id string) error {
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+	res, err := r.coll.DeleteOne(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *promotionRepo) List(ctx context.Context, limit, page int) ([]*domain.Promotion, int64, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if page <= 0 {
+		page = 1
+	}
+	skip := int64((page - 1) * limit)
+	findOptions := options.Find().SetLimit(int64(limit)).SetSkip(skip).SetSort(bson.D{{Key: "created_at", Value: -1}})
+	out, err := r.find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := r.coll.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+// ListActive returns the enabled promotions whose validity window contains
+// at.
+func (r *promotionRepo) ListActive(ctx context.Context, at time.Time) ([]*domain.Promotion, error) {
+	filter := bson.M{
+		"active": true,
+		"$and": bson.A{
+			bson.M{"$or": bson.A{bson.M{"starts_at": bson.M{"$exists": false}}, bson.M{"starts_at": bson.M{"$lte": at}}}},
+			bson.M{"$or": bson.A{bson.M{"ends_at": bson.M{"$exists": false}}, bson.M{"ends_at": bson.M{"$gt": at}}}},
+		},
+	}
+	return r.find(ctx, filter, options.Find().SetSort(bson.D{{Key: "priority", Value: -1}}))
+}
+
+func (r *promotionRepo) find(ctx context.Context, filter bson.M, opts *options.FindOptionsBuilder) ([]*domain.Promotion, error) {
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	out := []*domain.Promotion{}
+	for cur.Next(ctx) {
+		var p domain.Promotion
+		if err := cur.Decode(&p); err != nil {
+			return nil, err
+		}
+		out = append(out, &p)
+	}
+	return out, cur.Err()
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/database"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+type refundRepo struct {
+	coll   *mongo.Collection
+	logger *zap.Logger
+}
+
+func NewRefundRepository(db *database.MongoDB, logger *zap.Logger) RefundRepository {
+	c := db.Collection("refunds")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mods := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "order_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	}
+	if _, err := c.Indexes().CreateMany(ctx, mods); err != nil {
+		logger.Warn("could not create refund indexes", zap.Error(err))
+	}
+	return &refundRepo{coll: c, logger: logger}
+}
+
+func (r *refundRepo) Create(ctx context.Context, rf *domain.Refund) error {
+	now := time.Now().UTC()
+	rf.CreatedAt = now
+	rf.UpdatedAt = now
+	res, err := r.coll.InsertOne(ctx, rf)
+	if err != nil {
+		return err
+	}
+	oid := res.InsertedID.(bson.ObjectID)
+	rf.ID = oid.Hex()
+	return nil
+}
+
+// Settle records the outcome of a pending refund. It returns ErrNotFound if
+// the refund is no longer pending.
+func (r *refundRepo) Settle(ctx context.Context, id string, status domain.RefundStatus, providerRef, failureReason string) error {
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+	set := bson.M{"status": status, "updated_at": time.Now().UTC()}
+	if providerRef != "" {
+		set["provider_ref"] = providerRef
+	}
+	if failureReason != "" {
+		set["failure_reason"] = failureReason
+	}
+	res, err := r.coll.UpdateOne(ctx, bson.M{"_id": oid, "status": domain.RefundPending}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *refundRepo) ListByOrder(ctx context.Context, orderID string) ([]*domain.Refund, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cur, err := r.coll.Find(ctx, bson.M{"order_id": orderID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	out := []*domain.Refund{}
+	for cur.Next(ctx) {
+		var rf domain.Refund
+		if err := cur.Decode(&rf); err != nil {
+			return nil, err
+		}
+		out = append(out, &rf)
+	}
+	return out, cur.Err()
+}
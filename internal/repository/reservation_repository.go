@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/database"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+type reservationRepo struct {
+	coll   *mongo.Collection
+	logger *zap.Logger
+}
+
+func NewReservationRepository(db *database.MongoDB, logger *zap.Logger) ReservationRepository {
+	c := db.Collection("reservations")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mods := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "order_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}}},
+		{Keys: bson.D{{Key: "lines.product_id", Value: 1}, {Key: "status", Value: 1}}},
+	}
+	if _, err := c.Indexes().CreateMany(ctx, mods); err != nil {
+		logger.Warn("could not create reservation indexes", zap.Error(err))
+	}
+	return &reservationRepo{coll: c, logger: logger}
+}
+
+func (r *reservationRepo) Create(ctx context.Context, rv *domain.Reservation) error {
+	now := time.Now().UTC()
+	rv.CreatedAt = now
+	rv.UpdatedAt = now
+	res, err := r.coll.InsertOne(ctx, rv)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	oid := res.InsertedID.(bson.ObjectID)
+	rv.ID = oid.Hex()
+	return nil
+}
+
+func (r *reservationRepo) GetByOrder(ctx context.Context, orderID string) (*domain.Reservation, error) {
+	var rv domain.Reservation
+	if err := r.coll.FindOne(ctx, bson.M{"order_id": orderID}).Decode(&rv); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &rv, nil
+}
+
+// Close moves the order's active reservation to status. Only active
+// reservations change, so of a concurrent payment and expiry exactly one
+// wins; ErrNotFound is returned when there is no active reservation.
+func (r *reservationRepo) Close(ctx context.Context, orderID string, status domain.ReservationStatus, reason string) error {
+	set := bson.M{"status": status, "updated_at": time.Now().UTC()}
+	if reason != "" {
+		set["release_reason"] = reason
+	}
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"order_id": orderID, "status": domain.ReservationActive},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListExpired returns up to limit active reservations that expired at or
+// before now, oldest expiry first. With after set, only reservations sorting
+// after it are returned, so callers can page past ones they could not
+// release.
+func (r *reservationRepo) ListExpired(ctx context.Context, now time.Time, after *domain.Reservation, limit int) ([]*domain.Reservation, error) {
+	filter := bson.M{
+		"status":     domain.ReservationActive,
+		"expires_at": bson.M{"$lte": now},
+	}
+	if after != nil && after.ExpiresAt != nil {
+		oid, err := bson.ObjectIDFromHex(after.ID)
+		if err != nil {
+			return nil, err
+		}
+		filter["$or"] = bson.A{
+			bson.M{"expires_at": bson.M{"$gt": *after.ExpiresAt}},
+			bson.M{"expires_at": *after.ExpiresAt, "_id": bson.M{"$gt": oid}},
+		}
+	}
+	opts := options.Find().SetLimit(int64(limit)).SetSort(bson.D{{Key: "expires_at", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	out := []*domain.Reservation{}
+	for cur.Next(ctx) {
+		var rv domain.Reservation
+		if err := cur.Decode(&rv); err != nil {
+			return nil, err
+		}
+		out = append(out, &rv)
+	}
+	return out, cur.Err()
+}
+
+// List returns one page of reservations, newest first, optionally only
+// those in status or holding productID.
+func (r *reservationRepo) List(ctx context.Context, status domain.ReservationStatus, productID string, limit, page int) ([]*domain.Reservation, int64, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if page <= 0 {
+		page = 1
+	}
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	if productID != "" {
+		filter["lines.product_id"] = productID
+	}
+	skip := int64((page - 1) * limit)
+	findOptions := options.Find().SetLimit(int64(limit)).SetSkip(skip).SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cur, err := r.coll.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cur.Close(ctx)
+	out := []*domain.Reservation{}
+	for cur.Next(ctx) {
+		var rv domain.Reservation
+		if err := cur.Decode(&rv); err != nil {
+			return nil, 0, err
+		}
+		out = append(out, &rv)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, 0, err
+	}
+	total, err := r.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+// Reserved totals the units held by active reservations per product, most
+// reserved first. A non-empty productID limits the result to that product.
+// Available is left for the caller to fill in from the product.
+func (r *reservationRepo) Reserved(ctx context.Context, productID string) ([]domain.StockLevel, error) {
+	match := bson.M{"status": domain.ReservationActive}
+	lineMatch := bson.M{}
+	if productID != "" {
+		match["lines.product_id"] = productID
+		lineMatch["lines.product_id"] = productID
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$lines"}},
+		{{Key: "$match", Value: lineMatch}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$lines.product_id",
+			"sku":      bson.M{"$first": "$lines.sku"},
+			"reserved": bson.M{"$sum": "$lines.quantity"},
+			"orders":   bson.M{"$addToSet": "$order_id"},
+		}}},
+		{{Key: "$set", Value: bson.M{"orders": bson.M{"$size": "$orders"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "reserved", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+	cur, err := r.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	out := []domain.StockLevel{}
+	for cur.Next(ctx) {
+		var l domain.StockLevel
+		if err := cur.Decode(&l); err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, cur.Err()
+}
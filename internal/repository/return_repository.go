@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/database"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+type returnRepo struct {
+	coll   *mongo.Collection
+	logger *zap.Logger
+}
+
+func NewReturnRepository(db *database.MongoDB, logger *zap.Logger) ReturnRepository {
+	c := db.Collection("returns")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mods := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "order_id", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+	}
+	if _, err := c.Indexes().CreateMany(ctx, mods); err != nil {
+		logger.Warn("could not create return indexes", zap.Error(err))
+	}
+	return &returnRepo{coll: c, logger: logger}
+}
+
+func (r *returnRepo) Create(ctx context.Context, rt *domain.Return) error {
+	now := time.Now().UTC()
+	rt.CreatedAt = now
+	rt.UpdatedAt = now
+	res, err := r.coll.InsertOne(ctx, rt)
+	if err != nil {
+		return err
+	}
+	oid := res.InsertedID.(bson.ObjectID)
+	rt.ID = oid.Hex()
+	return nil
+}
+
+func (r *returnRepo) GetByID(ctx context.Context, id string) (*domain.Return, error) {
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	var rt domain.Return
+	if err := r.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&rt); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &rt, nil
+}
+
+func (r *returnRepo) ListByUser(ctx context.Context, userID string) ([]*domain.Return, error) {
+	return r.find(ctx, bson.M{"user_id": userID})
+}
+
+func (r *returnRepo) ListByOrder(ctx context.Context, orderID string) ([]*domain.Return, error) {
+	return r.find(ctx, bson.M{"order_id": orderID})
+}
+
+// find returns the returns matching filter, newest first.
+func (r *returnRepo) find(ctx context.Context, filter bson.M) ([]*domain.Return, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	out := []*domain.Return{}
+	for cur.Next(ctx) {
+		var rt domain.Return
+		if err := cur.Decode(&rt); err != nil {
+			return nil, err
+		}
+		out = append(out, &rt)
+	}
+	return out, cur.Err()
+}
+
+// List returns one page of returns, newest first, optionally only those in
+// status.
+func (r *returnRepo) List(ctx context.Context, status domain.ReturnStatus, limit, page int) ([]*domain.Return, int64, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if page <= 0 {
+		page = 1
+	}
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	skip := int64((page - 1) * limit)
+	findOptions := options.Find().SetLimit(int64(limit)).SetSkip(skip).SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cur, err := r.coll.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cur.Close(ctx)
+	out := []*domain.Return{}
+	for cur.Next(ctx) {
+		var rt domain.Return
+		if err := cur.Decode(&rt); err != nil {
+			return nil, 0, err
+		}
+		out = append(out, &rt)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, 0, err
+	}
+	total, err := r.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+// UpdateStatus moves the return to change.To, saving its restock and refund
+// details with it. The update only applies while the return is still in the
+// change.From status; ErrNotFound is returned otherwise.
+func (r *returnRepo) UpdateStatus(ctx context.Context, rt *domain.Return, change domain.ReturnStatusChange) error {
+	oid, err := bson.ObjectIDFromHex(rt.ID)
+	if err != nil {
+		return ErrNotFound
+	}
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": oid, "status": change.From},
+		bson.M{
+			"$set": bson.M{
+				"status":     change.To,
+				"restocked":  rt.Restocked,
+				"refund_id":  rt.RefundID,
+				"updated_at": change.At,
+			},
+			"$push": bson.M{"history": change},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetRefund links the refund issued for a return.
+func (r *returnRepo) SetRefund(ctx context.Context, id, refundID string) error {
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": oid},
+		bson.M{"$set": bson.M{"refund_id": refundID, "updated_at": time.Now().UTC()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
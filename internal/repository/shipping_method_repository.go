@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/database"
+	"github.com/rseigha/goecomapi/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+type shippingMethodRepo struct {
+	coll   *mongo.Collection
+	logger *zap.Logger
+}
+
+func NewShippingMethodRepository(db *database.MongoDB, logger *zap.Logger) ShippingMethodRepository {
+	c := db.Collection("shipping_methods")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mod := mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	if _, err := c.Indexes().CreateOne(ctx, mod); err != nil {
+		logger.Warn("could not create shipping method indexes", zap.Error(err))
+	}
+	return &shippingMethodRepo{coll: c, logger: logger}
+}
+
+func (r *shippingMethodRepo) Create(ctx context.Context, m *domain.ShippingMethod) error {
+	now := time.Now().UTC()
+	m.CreatedAt = now
+	m.UpdatedAt = now
+	res, err := r.coll.InsertOne(ctx, m)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	oid := res.InsertedID.(bson.ObjectID)
+	m.ID = oid.Hex()
+	return nil
+}
+
+func (r *shippingMethodRepo) GetByID(ctx context.Context, id string) (*domain.ShippingMethod, error) {
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	return r.findOne(ctx, bson.M{"_id": oid})
+}
+
+func (r *shippingMethodRepo) GetByCode(ctx context.Context, code string) (*domain.ShippingMethod, error) {
+	return r.findOne(ctx, bson.M{"code": code})
+}
+
+func (r *shippingMethodRepo) findOne(ctx context.Context, filter bson.M) (*domain.ShippingMethod, error) {
+	var m domain.ShippingMethod
+	if err := r.coll.FindOne(ctx, filter).Decode(&m); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Update replaces the method's settings, keeping its creation time.
+func (r *shippingMethodRepo) Update(ctx context.Context, m *domain.ShippingMethod) error {
+	oid, err := bson.ObjectIDFromHex(m.ID)
+	if err != nil {
+		return ErrNotFound
+	}
+	m.UpdatedAt = time.Now().UTC()
+	res, err := r.coll.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": bson.M{
+		"code":         m.Code,
+		"name":         m.Name,
+		"type":         m.Type,
+		"cost":         m.Cost,
+		"free_over":    m.FreeOver,
+		"weight_tiers": m.WeightTiers,
+		"countries":    m.Countries,
+		"active":       m.Active,
+		"updated_at":   m.UpdatedAt,
+	}})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *shippingMethodRepo) Delete(ctx context.Context, id string) error {
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+	res, err := r.coll.DeleteOne(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List returns the catalogue ordered by name.
+func (r *shippingMethodRepo) List(ctx context.Context, activeOnly bool) ([]*domain.ShippingMethod, error) {
+	filter := bson.M{}
+	if activeOnly {
+		filter["active"] = true
+	}
+	cur, err := r.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	out := []*domain.ShippingMethod{}
+	for cur.Next(ctx) {
+		var m domain.ShippingMethod
+		if err := cur.Decode(&m); err != nil {
+			return nil, err
+		}
+		out = append(out, &m)
+	}
+	return out, cur.Err()
+}
@@ -64,11 +64,14 @@ func (r *userRepo) Create(ctx context.Context, u *domain.User) error {
 func (r *userRepo) GetByID(ctx context.Context, id string) (*domain.User, error) {
 	oid, err := bson.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return nil, ErrNotFound
 	}
 
 	var u domain.User
 	if err := r.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&u); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
 		return nil, err
 	}
 
@@ -95,6 +98,28 @@ func (r *userRepo) Update(ctx context.Context, u *domain.User) error {
 	return err
 }
 
+// SaveAddresses replaces the user's address book.
+func (r *userRepo) SaveAddresses(ctx context.Context, userID string, addrs []domain.Address) error {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrNotFound
+	}
+	if addrs == nil {
+		addrs = []domain.Address{}
+	}
+	res, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": oid},
+		bson.M{"$set": bson.M{"addresses": addrs, "updated_at": time.Now().UTC()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 func (r *userRepo) Delete(ctx context.Context, id string) error {
 	oid, err := bson.ObjectIDFromHex(id)
 	if err != nil {
@@ -6,31 +6,57 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/rseigha/goecomapi/internal/handler"
 	"github.com/rseigha/goecomapi/internal/middleware"
+	"github.com/rseigha/goecomapi/internal/repository"
 	jwtpkg "github.com/rseigha/goecomapi/pkg/jwt"
 	"go.uber.org/zap"
 )
 
 type RouterConfig struct {
-	AuthHandler    *handler.AuthHandler
-	UserHandler    *handler.UserHandler
-	ProductHandler *handler.ProductHandler
-	OrderHandler   *handler.OrderHandler
-	JWT            *jwtpkg.JWT
-	Logger         *zap.Logger
+	AuthHandler        *handler.AuthHandler
+	UserHandler        *handler.UserHandler
+	ProductHandler     *handler.ProductHandler
+	OrderHandler       *handler.OrderHandler
+	CartHandler        *handler.CartHandler
+	CheckoutHandler    *handler.CheckoutHandler
+	PaymentHandler     *handler.PaymentHandler
+	RefundHandler      *handler.RefundHandler
+	CouponHandler      *handler.CouponHandler
+	PromotionHandler   *handler.PromotionHandler
+	AddressHandler     *handler.AddressHandler
+	ShippingHandler    *handler.ShippingHandler
+	ShipmentHandler    *handler.ShipmentHandler
+	ReturnHandler      *handler.ReturnHandler
+	InvoiceHandler     *handler.InvoiceHandler
+	ReservationHandler *handler.ReservationHandler
+	Idempotency        repository.IdempotencyRepository
+	JWT                *jwtpkg.JWT
+	Logger             *zap.Logger
 }
 
 func NewRouter(cfg *RouterConfig) *mux.Router {
 	r := mux.NewRouter()
 	api := r.PathPrefix("/api/v1").Subrouter()
 
+	// idempotent wraps a handler so retries carrying the same
+	// Idempotency-Key header get the original response back; payment client
+	// secrets are left out of the stored copy
+	idempotency := middleware.Idempotency(cfg.Idempotency, cfg.Logger, "client_secret")
+	idempotent := func(h http.HandlerFunc) http.Handler { return idempotency(h) }
+
 	// public
-	api.HandleFunc("/auth/register", cfg.AuthHandler.Register).Methods("POST")
+	api.Handle("/auth/register", idempotent(cfg.AuthHandler.Register)).Methods("POST")
 	api.HandleFunc("/auth/login", cfg.AuthHandler.Login).Methods("POST")
 
+	// payment provider callbacks, verified by signature
+	api.HandleFunc("/payments/webhook/{provider}", cfg.PaymentHandler.Webhook).Methods("POST")
+
 	// products: list and get are public
 	api.HandleFunc("/products", cfg.ProductHandler.List).Methods("GET")
 	api.HandleFunc("/products/{id}", cfg.ProductHandler.Get).Methods("GET")
 
+	// shipping methods customers can choose from
+	api.HandleFunc("/shipping-methods", cfg.ShippingHandler.Available).Methods("GET")
+
 	// protected routes
 	authMiddleware := middleware.JWTAuth(cfg.JWT, cfg.Logger)
 
@@ -41,14 +67,104 @@ func NewRouter(cfg *RouterConfig) *mux.Router {
 	userRouter.Use(authMiddleware)
 
 	orderRouter := api.PathPrefix("/orders").Subrouter()
-	orderRouter.HandleFunc("", cfg.OrderHandler.Create).Methods("POST")
+	orderRouter.Handle("", idempotent(cfg.OrderHandler.Create)).Methods("POST")
 	orderRouter.HandleFunc("", cfg.OrderHandler.ListByUser).Methods("GET")
+	orderRouter.HandleFunc("/by-number/{number}", cfg.OrderHandler.GetByNumber).Methods("GET")
+	orderRouter.HandleFunc("/{id}", cfg.OrderHandler.Get).Methods("GET")
+	orderRouter.HandleFunc("/{id}/cancel", cfg.OrderHandler.Cancel).Methods("POST")
+	orderRouter.Handle("/{id}/payments", idempotent(cfg.PaymentHandler.Start)).Methods("POST")
+	orderRouter.HandleFunc("/{id}/payments", cfg.PaymentHandler.ListByOrder).Methods("GET")
+	orderRouter.Handle("/{id}/returns", idempotent(cfg.ReturnHandler.Create)).Methods("POST")
+	orderRouter.HandleFunc("/{id}/invoice", cfg.InvoiceHandler.Get).Methods("GET")
 	orderRouter.Use(authMiddleware)
 
+	returnRouter := api.PathPrefix("/returns").Subrouter()
+	returnRouter.HandleFunc("", cfg.ReturnHandler.ListByUser).Methods("GET")
+	returnRouter.HandleFunc("/{id}", cfg.ReturnHandler.Get).Methods("GET")
+	returnRouter.HandleFunc("/{id}/cancel", cfg.ReturnHandler.Cancel).Methods("POST")
+	returnRouter.Use(authMiddleware)
+
+	addressRouter := api.PathPrefix("/addresses").Subrouter()
+	addressRouter.HandleFunc("", cfg.AddressHandler.List).Methods("GET")
+	addressRouter.HandleFunc("", cfg.AddressHandler.Create).Methods("POST")
+	addressRouter.HandleFunc("/{addressId}", cfg.AddressHandler.Update).Methods("PUT")
+	addressRouter.HandleFunc("/{addressId}", cfg.AddressHandler.Delete).Methods("DELETE")
+	addressRouter.Use(authMiddleware)
+
+	cartRouter := api.PathPrefix("/cart").Subrouter()
+	cartRouter.HandleFunc("", cfg.CartHandler.Get).Methods("GET")
+	cartRouter.HandleFunc("", cfg.CartHandler.Clear).Methods("DELETE")
+	cartRouter.HandleFunc("/items", cfg.CartHandler.AddItem).Methods("POST")
+	cartRouter.HandleFunc("/items/{productId}", cfg.CartHandler.UpdateItem).Methods("PUT")
+	cartRouter.HandleFunc("/items/{productId}", cfg.CartHandler.RemoveItem).Methods("DELETE")
+	// carts work for guests too, identified by the X-Cart-Token header
+	cartRouter.Use(middleware.OptionalJWTAuth(cfg.JWT, cfg.Logger))
+
+	checkoutRouter := api.PathPrefix("/checkout").Subrouter()
+	checkoutRouter.Handle("", idempotent(cfg.CheckoutHandler.Checkout)).Methods("POST")
+	checkoutRouter.Use(authMiddleware)
+
+	// admin order routes
+	adminOrderRouter := api.PathPrefix("/orders").Subrouter()
+	adminOrderRouter.HandleFunc("/{id}/status", cfg.OrderHandler.UpdateStatus).Methods("PATCH")
+	adminOrderRouter.Use(authMiddleware, middleware.RequireRole("admin"))
+
+	// admin order search, refunds and fulfillment across all users
+	adminOrderSearchRouter := api.PathPrefix("/admin/orders").Subrouter()
+	adminOrderSearchRouter.HandleFunc("", cfg.OrderHandler.Search).Methods("GET")
+	adminOrderSearchRouter.HandleFunc("/export", cfg.OrderHandler.Export).Methods("GET")
+	adminOrderSearchRouter.Handle("/{id}/refunds", idempotent(cfg.RefundHandler.Create)).Methods("POST")
+	adminOrderSearchRouter.HandleFunc("/{id}/refunds", cfg.RefundHandler.ListByOrder).Methods("GET")
+	adminOrderSearchRouter.Handle("/{id}/shipments", idempotent(cfg.ShipmentHandler.Create)).Methods("POST")
+	adminOrderSearchRouter.HandleFunc("/{id}/shipments", cfg.ShipmentHandler.ListByOrder).Methods("GET")
+	adminOrderSearchRouter.HandleFunc("/{id}/shipments/{shipmentId}", cfg.ShipmentHandler.UpdateTracking).Methods("PATCH")
+	adminOrderSearchRouter.Use(authMiddleware, middleware.RequireRole("admin"))
+
+	// admin coupon management
+	adminCouponRouter := api.PathPrefix("/admin/coupons").Subrouter()
+	adminCouponRouter.HandleFunc("", cfg.CouponHandler.Create).Methods("POST")
+	adminCouponRouter.HandleFunc("", cfg.CouponHandler.List).Methods("GET")
+	adminCouponRouter.HandleFunc("/{id}", cfg.CouponHandler.Get).Methods("GET")
+	adminCouponRouter.HandleFunc("/{id}", cfg.CouponHandler.Update).Methods("PUT")
+	adminCouponRouter.HandleFunc("/{id}", cfg.CouponHandler.Delete).Methods("DELETE")
+	adminCouponRouter.Use(authMiddleware, middleware.RequireRole("admin"))
+
+	// admin promotion rules
+	adminPromotionRouter := api.PathPrefix("/admin/promotions").Subrouter()
+	adminPromotionRouter.HandleFunc("", cfg.PromotionHandler.Create).Methods("POST")
+	adminPromotionRouter.HandleFunc("", cfg.PromotionHandler.List).Methods("GET")
+	adminPromotionRouter.HandleFunc("/{id}", cfg.PromotionHandler.Get).Methods("GET")
+	adminPromotionRouter.HandleFunc("/{id}", cfg.PromotionHandler.Update).Methods("PUT")
+	adminPromotionRouter.HandleFunc("/{id}", cfg.PromotionHandler.Delete).Methods("DELETE")
+	adminPromotionRouter.Use(authMiddleware, middleware.RequireRole("admin"))
+
+	// admin returns processing
+	adminReturnRouter := api.PathPrefix("/admin/returns").Subrouter()
+	adminReturnRouter.HandleFunc("", cfg.ReturnHandler.List).Methods("GET")
+	adminReturnRouter.HandleFunc("/{id}", cfg.ReturnHandler.Get).Methods("GET")
+	adminReturnRouter.HandleFunc("/{id}/status", cfg.ReturnHandler.UpdateStatus).Methods("PATCH")
+	adminReturnRouter.Use(authMiddleware, middleware.RequireRole("admin"))
+
+	// admin view of stock held for unpaid orders
+	adminReservationRouter := api.PathPrefix("/admin/reservations").Subrouter()
+	adminReservationRouter.HandleFunc("", cfg.ReservationHandler.List).Methods("GET")
+	adminReservationRouter.HandleFunc("/stock", cfg.ReservationHandler.Stock).Methods("GET")
+	adminReservationRouter.Use(authMiddleware, middleware.RequireRole("admin"))
+
+	// admin shipping catalogue
+	adminShippingRouter := api.PathPrefix("/admin/shipping-methods").Subrouter()
+	adminShippingRouter.HandleFunc("", cfg.ShippingHandler.Create).Methods("POST")
+	adminShippingRouter.HandleFunc("", cfg.ShippingHandler.List).Methods("GET")
+	adminShippingRouter.HandleFunc("/{id}", cfg.ShippingHandler.Get).Methods("GET")
+	adminShippingRouter.HandleFunc("/{id}", cfg.ShippingHandler.Update).Methods("PUT")
+	adminShippingRouter.HandleFunc("/{id}", cfg.ShippingHandler.Delete).Methods("DELETE")
+	adminShippingRouter.Use(authMiddleware, middleware.RequireRole("admin"))
+
 	// admin product routes
 	adminRouter := api.PathPrefix("/products").Subrouter()
 	adminRouter.HandleFunc("", cfg.ProductHandler.Create).Methods("POST")
 	adminRouter.HandleFunc("/{id}", cfg.ProductHandler.Update).Methods("PUT")
+	adminRouter.HandleFunc("/{id}/stock", cfg.ProductHandler.AdjustStock).Methods("POST")
 	adminRouter.HandleFunc("/{id}", cfg.ProductHandler.Delete).Methods("DELETE")
 	adminRouter.Use(authMiddleware, middleware.RequireRole("admin"))
 
@@ -59,4 +175,4 @@ func NewRouter(cfg *RouterConfig) *mux.Router {
 	}).Methods("GET")
 
 	return r
-}
\ No newline at end of file
+}
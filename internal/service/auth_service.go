@@ -12,21 +12,24 @@ import (
 	"go.uber.org/zap"
 )
 
+// Register and Login accept an optional guest cart token; the guest cart is
+// merged into the user's cart once they are signed in.
 type AuthService interface {
-	Register(ctx context.Context, name, email, password string) (*domain.User, error)
-	Login(ctx context.Context, email, password string) (string, error) // returns JWT token
+	Register(ctx context.Context, name, email, password, cartToken string) (*domain.User, error)
+	Login(ctx context.Context, email, password, cartToken string) (string, error) // returns JWT token
 }
 
 type authService struct {
 	userRepo repository.UserRepository
+	carts    CartService
 	jwt      *jwtpkg.JWT
 	logger   *zap.Logger
 }
 
-func NewAuthService(u repository.UserRepository, j *jwtpkg.JWT, logger *zap.Logger) AuthService {
-	return &authService{userRepo: u, jwt: j, logger: logger}
+func NewAuthService(u repository.UserRepository, c CartService, j *jwtpkg.JWT, logger *zap.Logger) AuthService {
+	return &authService{userRepo: u, carts: c, jwt: j, logger: logger}
 }
-func (s *authService) Register(ctx context.Context, name, email, password string) (*domain.User, error) {
+func (s *authService) Register(ctx context.Context, name, email, password, cartToken string) (*domain.User, error) {
 	// check existing user
 	existing, _ := s.userRepo.GetByEmail(ctx, email)
 	if existing != nil {
@@ -47,11 +50,12 @@ func (s *authService) Register(ctx context.Context, name, email, password string
 	if err := s.userRepo.Create(ctx, u); err != nil {
 		return nil, err
 	}
+	s.mergeCart(ctx, cartToken, u.ID)
 	u.PasswordHash = "" // redact
 	return u, nil
 }
 
-func (s *authService) Login(ctx context.Context, email, password string) (string, error) {
+func (s *authService) Login(ctx context.Context, email, password, cartToken string) (string, error) {
 	u, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
 		return "", errors.New("invalid credentials")
@@ -72,5 +76,17 @@ func (s *authService) Login(ctx context.Context, email, password string) (string
 	if err != nil {
 		return "", err
 	}
+	s.mergeCart(ctx, cartToken, u.ID)
 	return token, nil
 }
+
+// mergeCart never fails the sign-in; a cart that could not be merged stays
+// available under its guest token.
+func (s *authService) mergeCart(ctx context.Context, cartToken, userID string) {
+	if cartToken == "" {
+		return
+	}
+	if err := s.carts.MergeGuest(ctx, cartToken, userID); err != nil {
+		s.logger.Warn("could not merge guest cart", zap.String("user_id", userID), zap.Error(err))
+	}
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/promotion"
+	"github.com/rseigha/goecomapi/internal/repository"
+)
+
+var ErrCartItemNotFound = errors.New("item not in cart")
+
+const maxCartLineQuantity = 999
+
+type CartService interface {
+	Get(ctx context.Context, owner domain.CartOwner) (*domain.Cart, error)
+	AddItem(ctx context.Context, owner domain.CartOwner, productID string, qty int) (*domain.Cart, error)
+	UpdateItem(ctx context.Context, owner domain.CartOwner, productID string, qty int) (*domain.Cart, error)
+	RemoveItem(ctx context.Context, owner domain.CartOwner, productID string) (*domain.Cart, error)
+	Clear(ctx context.Context, owner domain.CartOwner) error
+	MergeGuest(ctx context.Context, token, userID string) error
+}
+
+type cartService struct {
+	repo        repository.CartRepository
+	productRepo repository.ProductRepository
+	promotions  PromotionService
+}
+
+func NewCartService(r repository.CartRepository, pr repository.ProductRepository, promotions PromotionService) CartService {
+	return &cartService{repo: r, productRepo: pr, promotions: promotions}
+}
+
+// Get returns the cart priced from the current catalog. An owner who never
+// added anything gets an empty cart.
+func (s *cartService) Get(ctx context.Context, owner domain.CartOwner) (*domain.Cart, error) {
+	c, err := s.load(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.price(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// AddItem adds qty units of a product, merging with an existing line. A guest
+// without a token gets a new guest cart; its token is returned on the cart.
+func (s *cartService) AddItem(ctx context.Context, owner domain.CartOwner, productID string, qty int) (*domain.Cart, error) {
+	if qty <= 0 || qty > maxCartLineQuantity {
+		return nil, fmt.Errorf("%w: must be between 1 and %d", ErrInvalidQuantity, maxCartLineQuantity)
+	}
+	if _, err := s.product(ctx, productID); err != nil {
+		return nil, err
+	}
+	c, err := s.load(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if owner.IsGuest() && c.Token == "" {
+		if c.Token, err = newCartToken(); err != nil {
+			return nil, err
+		}
+	}
+	found := false
+	for i := range c.Items {
+		if c.Items[i].ProductID == productID {
+			c.Items[i].Quantity = min(c.Items[i].Quantity+qty, maxCartLineQuantity)
+			found = true
+			break
+		}
+	}
+	if !found {
+		c.Items = append(c.Items, domain.CartItem{ProductID: productID, Quantity: qty, AddedAt: time.Now().UTC()})
+	}
+	return s.save(ctx, c)
+}
+
+// UpdateItem sets the quantity of a line; zero removes it.
+func (s *cartService) UpdateItem(ctx context.Context, owner domain.CartOwner, productID string, qty int) (*domain.Cart, error) {
+	if qty < 0 || qty > maxCartLineQuantity {
+		return nil, fmt.Errorf("%w: must be between 0 and %d", ErrInvalidQuantity, maxCartLineQuantity)
+	}
+	if qty == 0 {
+		return s.RemoveItem(ctx, owner, productID)
+	}
+	c, err := s.load(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	i := cartLine(c, productID)
+	if i < 0 {
+		return nil, ErrCartItemNotFound
+	}
+	c.Items[i].Quantity = qty
+	return s.save(ctx, c)
+}
+
+func (s *cartService) RemoveItem(ctx context.Context, owner domain.CartOwner, productID string) (*domain.Cart, error) {
+	c, err := s.load(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	i := cartLine(c, productID)
+	if i < 0 {
+		return nil, ErrCartItemNotFound
+	}
+	c.Items = append(c.Items[:i], c.Items[i+1:]...)
+	return s.save(ctx, c)
+}
+
+func (s *cartService) Clear(ctx context.Context, owner domain.CartOwner) error {
+	return s.repo.Delete(ctx, owner)
+}
+
+// MergeGuest moves a guest cart into the user's cart and deletes it. When
+// both carts hold the same product the larger quantity wins, so items the
+// shopper added on two devices are not double counted.
+func (s *cartService) MergeGuest(ctx context.Context, token, userID string) error {
+	if token == "" {
+		return nil
+	}
+	guestOwner := domain.CartOwner{Token: token}
+	guest, err := s.repo.Get(ctx, guestOwner)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c, err := s.load(ctx, domain.CartOwner{UserID: userID})
+	if err != nil {
+		return err
+	}
+	for _, it := range guest.Items {
+		if i := cartLine(c, it.ProductID); i >= 0 {
+			c.Items[i].Quantity = min(max(c.Items[i].Quantity, it.Quantity), maxCartLineQuantity)
+			continue
+		}
+		c.Items = append(c.Items, it)
+	}
+	if err := s.repo.Save(ctx, c); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, guestOwner)
+}
+
+func (s *cartService) load(ctx context.Context, owner domain.CartOwner) (*domain.Cart, error) {
+	c, err := s.repo.Get(ctx, owner)
+	if errors.Is(err, repository.ErrNotFound) {
+		// unknown or expired guest tokens are not reused; AddItem issues a
+		// fresh one
+		return &domain.Cart{UserID: owner.UserID, Items: []domain.CartItem{}}, nil
+	}
+	return c, err
+}
+
+func (s *cartService) save(ctx context.Context, c *domain.Cart) (*domain.Cart, error) {
+	if err := s.repo.Save(ctx, c); err != nil {
+		return nil, err
+	}
+	if err := s.price(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *cartService) product(ctx context.Context, id string) (*domain.Product, error) {
+	p, err := s.productRepo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProduct, id)
+	}
+	return p, err
+}
+
+// price fills the catalog details, line totals, subtotal, promotion
+// discounts and stock warnings. Carts are not rejected for problems here;
+// checkout re-validates them.
+func (s *cartService) price(ctx context.Context, c *domain.Cart) error {
+	c.Subtotal = domain.Money{}
+	c.Warnings = nil
+	var lines []promotion.Line
+	for i := range c.Items {
+		it := &c.Items[i]
+		p, err := s.productRepo.GetByID(ctx, it.ProductID)
+		if errors.Is(err, repository.ErrNotFound) {
+			it.Warning = "product is no longer available"
+			c.Warnings = append(c.Warnings, fmt.Sprintf("%s: %s", it.ProductID, it.Warning))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		it.Name = p.Name
+		it.SKU = p.SKU
+		it.UnitPrice = p.Price
+		it.LineTotal = p.Price.Mul(it.Quantity)
+		it.Available = p.Stock
+		switch {
+		case p.Stock <= 0:
+			it.Warning = "out of stock"
+		case it.Quantity > p.Stock:
+			it.Warning = fmt.Sprintf("only %d left in stock", p.Stock)
+		}
+		if it.Warning != "" {
+			c.Warnings = append(c.Warnings, fmt.Sprintf("%s: %s", p.SKU, it.Warning))
+		}
+		lines = append(lines, promotion.Line{ProductID: p.ID, Name: p.Name, Quantity: it.Quantity, UnitPrice: p.Price})
+		if sum, err := c.Subtotal.Add(it.LineTotal); err == nil {
+			c.Subtotal = sum
+		} else {
+			c.Warnings = append(c.Warnings, fmt.Sprintf("%s: priced in %s, cart is in %s", p.SKU, p.Price.Currency, c.Subtotal.Currency))
+		}
+	}
+	if c.Subtotal.Currency == "" {
+		c.Subtotal.Currency = domain.DefaultCurrency
+	}
+	adjustments, err := s.promotions.Apply(ctx, lines)
+	if err != nil {
+		return err
+	}
+	c.Adjustments = adjustments
+	c.Discount = domain.Money{Currency: c.Subtotal.Currency}
+	for _, a := range adjustments {
+		c.Discount.Amount += a.Amount.Amount
+	}
+	c.Discount.Amount = min(c.Discount.Amount, c.Subtotal.Amount)
+	return nil
+}
+
+func cartLine(c *domain.Cart, productID string) int {
+	for i, it := range c.Items {
+		if it.ProductID == productID {
+			return i
+		}
+	}
+	return -1
+}
+
+// newCartToken returns an unguessable token identifying a guest cart.
+func newCartToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
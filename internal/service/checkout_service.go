@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/repository"
+)
+
+var (
+	ErrEmptyCart    = errors.New("cart is empty")
+	ErrPriceChanged = errors.New("order total changed since the cart was priced")
+)
+
+// CheckoutOptions carries the shopper's choices for the order placed at
+// checkout. Every field is optional.
+type CheckoutOptions struct {
+	CouponCode string
+	// ExpectedTotal is the total the shopper was shown.
+	ExpectedTotal *domain.Money
+	// ShippingAddress and BillingAddress are resolved as in
+	// OrderService.CreateOrder.
+	ShippingAddress *domain.Address
+	BillingAddress  *domain.Address
+	ShippingMethod  string
+}
+
+type CheckoutService interface {
+	Checkout(ctx context.Context, userID string, opts CheckoutOptions) (*domain.Order, error)
+}
+
+type checkoutService struct {
+	carts  repository.CartRepository
+	orders OrderService
+	tx     repository.Transactor
+}
+
+func NewCheckoutService(c repository.CartRepository, o OrderService, tx repository.Transactor) CheckoutService {
+	return &checkoutService{carts: c, orders: o, tx: tx}
+}
+
+// Checkout turns the user's cart into an order and empties the cart. The
+// order is priced and stock is taken by OrderService.CreateOrder; everything
+// runs in one transaction so a failure leaves both cart and stock untouched.
+// The coupon, addresses and shipping method in opts are applied as in
+// CreateOrder. When opts.ExpectedTotal is given the order is only placed if
+// its total matches, protecting shoppers from prices that changed after they
+// reviewed the cart.
+func (s *checkoutService) Checkout(ctx context.Context, userID string, opts CheckoutOptions) (*domain.Order, error) {
+	owner := domain.CartOwner{UserID: userID}
+	var out *domain.Order
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		cart, err := s.carts.Get(ctx, owner)
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrEmptyCart
+		}
+		if err != nil {
+			return err
+		}
+		if len(cart.Items) == 0 {
+			return ErrEmptyCart
+		}
+		o := &domain.Order{
+			UserID:          userID,
+			CouponCode:      opts.CouponCode,
+			ShippingAddress: opts.ShippingAddress,
+			BillingAddress:  opts.BillingAddress,
+			ShippingMethod:  opts.ShippingMethod,
+		}
+		for _, it := range cart.Items {
+			o.Items = append(o.Items, domain.OrderItem{ProductID: it.ProductID, Quantity: it.Quantity})
+		}
+		if err := s.orders.CreateOrder(ctx, o); err != nil {
+			return err
+		}
+		if opts.ExpectedTotal != nil && *opts.ExpectedTotal != o.Total {
+			return fmt.Errorf("%w: expected %s, now %s", ErrPriceChanged, opts.ExpectedTotal, o.Total)
+		}
+		if err := s.carts.Delete(ctx, owner); err != nil {
+			return err
+		}
+		out = o
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
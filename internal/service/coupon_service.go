@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/repository"
+)
+
+var (
+	ErrCouponNotFound      = errors.New("coupon not found")
+	ErrInvalidCoupon       = errors.New("invalid coupon")
+	ErrDuplicateCoupon     = errors.New("coupon code already exists")
+	ErrCouponNotApplicable = errors.New("coupon cannot be applied")
+	ErrCouponLimitReached  = errors.New("coupon usage limit reached")
+)
+
+type CouponService interface {
+	Create(ctx context.Context, c *domain.Coupon) error
+	GetByID(ctx context.Context, id string) (*domain.Coupon, error)
+	Update(ctx context.Context, c *domain.Coupon) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, limit, page int) ([]*domain.Coupon, int64, error)
+
+	// Discount checks that code can be applied to the order by userID and
+	// returns the coupon with the discount it gives. Nothing is redeemed.
+	Discount(ctx context.Context, code, userID string, items []domain.OrderItem, subtotal domain.Money) (*domain.Coupon, domain.Money, error)
+	// Redeem records that orderID used the coupon, enforcing the usage
+	// limits. Call it in the transaction that creates the order.
+	Redeem(ctx context.Context, c *domain.Coupon, userID, orderID string, discount domain.Money) error
+	// Release gives back the coupon use of a canceled order.
+	Release(ctx context.Context, orderID string) error
+}
+
+type couponService struct {
+	repo repository.CouponRepository
+}
+
+func NewCouponService(r repository.CouponRepository) CouponService {
+	return &couponService{repo: r}
+}
+
+// NormalizeCouponCode returns code in the form coupons are stored under;
+// codes are matched case-insensitively.
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (s *couponService) Create(ctx context.Context, c *domain.Coupon) error {
+	if err := validateCoupon(c); err != nil {
+		return err
+	}
+	err := s.repo.Create(ctx, c)
+	if errors.Is(err, repository.ErrDuplicate) {
+		return fmt.Errorf("%w: %s", ErrDuplicateCoupon, c.Code)
+	}
+	return err
+}
+
+func (s *couponService) GetByID(ctx context.Context, id string) (*domain.Coupon, error) {
+	c, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrCouponNotFound
+	}
+	return c, err
+}
+
+func (s *couponService) Update(ctx context.Context, c *domain.Coupon) error {
+	if err := validateCoupon(c); err != nil {
+		return err
+	}
+	err := s.repo.Update(ctx, c)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return ErrCouponNotFound
+	case errors.Is(err, repository.ErrDuplicate):
+		return fmt.Errorf("%w: %s", ErrDuplicateCoupon, c.Code)
+	}
+	return err
+}
+
+func (s *couponService) Delete(ctx context.Context, id string) error {
+	err := s.repo.Delete(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrCouponNotFound
+	}
+	return err
+}
+
+func (s *couponService) List(ctx context.Context, limit, page int) ([]*domain.Coupon, int64, error) {
+	return s.repo.List(ctx, limit, page)
+}
+
+// validateCoupon normalizes the code and money fields and rejects settings
+// that could never apply.
+func validateCoupon(c *domain.Coupon) error {
+	c.Code = NormalizeCouponCode(c.Code)
+	if c.Code == "" {
+		return fmt.Errorf("%w: code is required", ErrInvalidCoupon)
+	}
+	switch c.Type {
+	case domain.CouponPercent:
+		if c.PercentBPS <= 0 || c.PercentBPS > 10000 {
+			return fmt.Errorf("%w: percent_bps must be between 1 and 10000", ErrInvalidCoupon)
+		}
+		c.AmountOff = domain.Money{}
+	case domain.CouponFixed:
+		if err := normalizePrice(&c.AmountOff); err != nil || c.AmountOff.Amount <= 0 {
+			return fmt.Errorf("%w: amount_off must be a positive amount", ErrInvalidCoupon)
+		}
+		c.PercentBPS = 0
+	default:
+		return fmt.Errorf("%w: type must be %q or %q", ErrInvalidCoupon, domain.CouponPercent, domain.CouponFixed)
+	}
+	if err := normalizePrice(&c.MinOrder); err != nil {
+		return fmt.Errorf("%w: min_order: %v", ErrInvalidCoupon, err)
+	}
+	if c.Type == domain.CouponFixed && c.MinOrder.Amount > 0 && c.MinOrder.Currency != c.AmountOff.Currency {
+		return fmt.Errorf("%w: min_order and amount_off must share a currency", ErrInvalidCoupon)
+	}
+	if c.MaxUses < 0 || c.MaxUsesPerUser < 0 {
+		return fmt.Errorf("%w: usage limits must not be negative", ErrInvalidCoupon)
+	}
+	if c.StartsAt != nil && c.EndsAt != nil && !c.StartsAt.Before(*c.EndsAt) {
+		return fmt.Errorf("%w: starts_at must be before ends_at", ErrInvalidCoupon)
+	}
+	return nil
+}
+
+func (s *couponService) Discount(ctx context.Context, code, userID string, items []domain.OrderItem, subtotal domain.Money) (*domain.Coupon, domain.Money, error) {
+	none := domain.Money{Currency: subtotal.Currency}
+	c, err := s.repo.GetByCode(ctx, NormalizeCouponCode(code))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, none, fmt.Errorf("%w: %s", ErrCouponNotFound, code)
+	}
+	if err != nil {
+		return nil, none, err
+	}
+	now := time.Now().UTC()
+	switch {
+	case !c.Active:
+		return nil, none, fmt.Errorf("%w: coupon is not active", ErrCouponNotApplicable)
+	case c.StartsAt != nil && now.Before(*c.StartsAt):
+		return nil, none, fmt.Errorf("%w: coupon is not valid yet", ErrCouponNotApplicable)
+	case c.EndsAt != nil && !now.Before(*c.EndsAt):
+		return nil, none, fmt.Errorf("%w: coupon has expired", ErrCouponNotApplicable)
+	case c.MaxUses > 0 && c.Uses >= c.MaxUses:
+		return nil, none, ErrCouponLimitReached
+	}
+	if c.MinOrder.Amount > 0 {
+		cmp, err := subtotal.Cmp(c.MinOrder)
+		if err != nil {
+			return nil, none, fmt.Errorf("%w: coupon is for %s orders", ErrCouponNotApplicable, c.MinOrder.Currency)
+		}
+		if cmp < 0 {
+			return nil, none, fmt.Errorf("%w: order must be at least %s", ErrCouponNotApplicable, c.MinOrder)
+		}
+	}
+	if c.MaxUsesPerUser > 0 {
+		n, err := s.repo.CountRedemptions(ctx, c.ID, userID)
+		if err != nil {
+			return nil, none, err
+		}
+		if n >= int64(c.MaxUsesPerUser) {
+			return nil, none, fmt.Errorf("%w: already used %d times", ErrCouponLimitReached, n)
+		}
+	}
+
+	eligible := couponEligible(c, items, subtotal)
+	if eligible.Amount == 0 {
+		return nil, none, fmt.Errorf("%w: no eligible products in the order", ErrCouponNotApplicable)
+	}
+	discount := none
+	switch c.Type {
+	case domain.CouponPercent:
+		discount = eligible.MulRatio(c.PercentBPS, 10000)
+	case domain.CouponFixed:
+		if c.AmountOff.Currency != subtotal.Currency {
+			return nil, none, fmt.Errorf("%w: coupon is for %s orders", ErrCouponNotApplicable, c.AmountOff.Currency)
+		}
+		// never discount more than the eligible lines are worth
+		discount = domain.NewMoney(min(c.AmountOff.Amount, eligible.Amount), subtotal.Currency)
+	}
+	return c, discount, nil
+}
+
+// couponAdjustment explains a coupon discount alongside promotions.
+func couponAdjustment(c *domain.Coupon, discount domain.Money) domain.Adjustment {
+	desc := c.AmountOff.String() + " off"
+	if c.Type == domain.CouponPercent {
+		desc = percentOffText(c.PercentBPS)
+	}
+	if len(c.ProductIDs) > 0 {
+		desc += " selected products"
+	}
+	return domain.Adjustment{CouponCode: c.Code, Name: "coupon " + c.Code, Description: desc, Amount: discount}
+}
+
+func percentOffText(bps int64) string {
+	if bps%100 == 0 {
+		return fmt.Sprintf("%d%% off", bps/100)
+	}
+	return fmt.Sprintf("%d.%02d%% off", bps/100, bps%100)
+}
+
+// couponEligible sums the order lines the coupon applies to.
+func couponEligible(c *domain.Coupon, items []domain.OrderItem, subtotal domain.Money) domain.Money {
+	if len(c.ProductIDs) == 0 {
+		return subtotal
+	}
+	allowed := make(map[string]bool, len(c.ProductIDs))
+	for _, id := range c.ProductIDs {
+		allowed[id] = true
+	}
+	sum := domain.Money{Currency: subtotal.Currency}
+	for _, it := range items {
+		if allowed[it.ProductID] {
+			sum.Amount += it.Price.Mul(it.Quantity).Amount
+		}
+	}
+	return sum
+}
+
+func (s *couponService) Redeem(ctx context.Context, c *domain.Coupon, userID, orderID string, discount domain.Money) error {
+	if c.MaxUsesPerUser > 0 {
+		// checked again here, inside the order's transaction
+		n, err := s.repo.CountRedemptions(ctx, c.ID, userID)
+		if err != nil {
+			return err
+		}
+		if n >= int64(c.MaxUsesPerUser) {
+			return fmt.Errorf("%w: already used %d times", ErrCouponLimitReached, n)
+		}
+	}
+	err := s.repo.Redeem(ctx, c, &domain.CouponRedemption{
+		CouponID: c.ID,
+		Code:     c.Code,
+		UserID:   userID,
+		OrderID:  orderID,
+		Amount:   discount,
+	})
+	switch {
+	case errors.Is(err, repository.ErrLimitReached):
+		return ErrCouponLimitReached
+	case errors.Is(err, repository.ErrNotFound):
+		return fmt.Errorf("%w: %s", ErrCouponNotFound, c.Code)
+	}
+	return err
+}
+
+func (s *couponService) Release(ctx context.Context, orderID string) error {
+	return s.repo.Release(ctx, orderID)
+}
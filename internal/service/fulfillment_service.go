@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/repository"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var (
+	ErrOrderNotShippable = errors.New("order cannot be shipped")
+	ErrInvalidShipment   = errors.New("invalid shipment")
+	ErrShipmentNotFound  = errors.New("shipment not found")
+)
+
+// ShipmentItem selects units of one ordered product to ship.
+type ShipmentItem struct {
+	ProductID string
+	Quantity  int
+}
+
+// ShipmentRequest describes a parcel leaving the warehouse. With no Items,
+// every unit not yet shipped or refunded is shipped. ShippedAt defaults to
+// now.
+type ShipmentRequest struct {
+	Carrier        string
+	TrackingNumber string
+	TrackingURL    string
+	ShippedAt      *time.Time
+	Items          []ShipmentItem
+}
+
+// TrackingUpdate corrects the carrier details of a recorded shipment. Empty
+// fields are left unchanged.
+type TrackingUpdate struct {
+	Carrier        string
+	TrackingNumber string
+	TrackingURL    string
+	ShippedAt      *time.Time
+}
+
+type FulfillmentService interface {
+	// Ship records a shipment and moves the order to shipped once every
+	// unit still owed has been sent, or to partially_shipped before that.
+	Ship(ctx context.Context, orderID, actorID string, req ShipmentRequest) (*domain.Shipment, error)
+	ListShipments(ctx context.Context, orderID string) ([]domain.Shipment, error)
+	UpdateTracking(ctx context.Context, orderID, shipmentID string, u TrackingUpdate) (*domain.Shipment, error)
+}
+
+type fulfillmentService struct {
+	orderRepo repository.OrderRepository
+	tx        repository.Transactor
+}
+
+func NewFulfillmentService(or repository.OrderRepository, tx repository.Transactor) FulfillmentService {
+	return &fulfillmentService{orderRepo: or, tx: tx}
+}
+
+func (s *fulfillmentService) Ship(ctx context.Context, orderID, actorID string, req ShipmentRequest) (*domain.Shipment, error) {
+	req.Carrier = strings.TrimSpace(req.Carrier)
+	if req.Carrier == "" {
+		return nil, fmt.Errorf("%w: carrier is required", ErrInvalidShipment)
+	}
+	for _, it := range req.Items {
+		if it.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidQuantity, it.ProductID)
+		}
+	}
+	var out *domain.Shipment
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		o, err := s.order(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if !canShip(o.Status) {
+			return fmt.Errorf("%w: %s orders cannot be shipped", ErrOrderNotShippable, o.Status)
+		}
+		lineQty, err := shipmentQuantities(o, req.Items)
+		if err != nil {
+			return err
+		}
+		if len(lineQty) == 0 {
+			return fmt.Errorf("%w: nothing left to ship", ErrOrderNotShippable)
+		}
+
+		now := time.Now().UTC()
+		sh := domain.Shipment{
+			ID:             bson.NewObjectID().Hex(),
+			Carrier:        req.Carrier,
+			TrackingNumber: strings.TrimSpace(req.TrackingNumber),
+			TrackingURL:    strings.TrimSpace(req.TrackingURL),
+			ShippedAt:      now,
+			CreatedBy:      actorID,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		if req.ShippedAt != nil {
+			sh.ShippedAt = req.ShippedAt.UTC()
+		}
+		for i, it := range o.Items {
+			if qty := lineQty[i]; qty > 0 {
+				sh.Lines = append(sh.Lines, domain.ShipmentLine{ProductID: it.ProductID, SKU: it.SKU, Name: it.Name, Quantity: qty})
+			}
+		}
+
+		target := domain.OrderShipped
+		for i, it := range o.Items {
+			if it.ShippedQuantity+lineQty[i]+it.RefundedQuantity < it.Quantity {
+				target = domain.OrderPartiallyShipped
+				break
+			}
+		}
+		var change *domain.StatusChange
+		if o.Status != target {
+			if !canTransition(o.Status, target) {
+				return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, o.Status, target)
+			}
+			change = &domain.StatusChange{
+				From:    o.Status,
+				To:      target,
+				At:      now,
+				ActorID: actorID,
+				Reason:  "shipment " + sh.ID,
+			}
+		}
+		if err := s.orderRepo.AddShipment(ctx, o, sh, lineQty, change); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return fmt.Errorf("%w: order was modified concurrently", ErrInvalidTransition)
+			}
+			return err
+		}
+		out = &sh
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *fulfillmentService) ListShipments(ctx context.Context, orderID string) ([]domain.Shipment, error) {
+	o, err := s.order(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if o.Shipments == nil {
+		return []domain.Shipment{}, nil
+	}
+	return o.Shipments, nil
+}
+
+func (s *fulfillmentService) UpdateTracking(ctx context.Context, orderID, shipmentID string, u TrackingUpdate) (*domain.Shipment, error) {
+	o, err := s.order(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	var sh *domain.Shipment
+	for i := range o.Shipments {
+		if o.Shipments[i].ID == shipmentID {
+			sh = &o.Shipments[i]
+			break
+		}
+	}
+	if sh == nil {
+		return nil, ErrShipmentNotFound
+	}
+	if v := strings.TrimSpace(u.Carrier); v != "" {
+		sh.Carrier = v
+	}
+	if v := strings.TrimSpace(u.TrackingNumber); v != "" {
+		sh.TrackingNumber = v
+	}
+	if v := strings.TrimSpace(u.TrackingURL); v != "" {
+		sh.TrackingURL = v
+	}
+	if u.ShippedAt != nil {
+		sh.ShippedAt = u.ShippedAt.UTC()
+	}
+	sh.UpdatedAt = time.Now().UTC()
+	if err := s.orderRepo.UpdateShipment(ctx, o.ID, *sh); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrShipmentNotFound
+		}
+		return nil, err
+	}
+	return sh, nil
+}
+
+func (s *fulfillmentService) order(ctx context.Context, id string) (*domain.Order, error) {
+	o, err := s.orderRepo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	return o, err
+}
+
+// shipmentQuantities maps the requested items onto order lines, returning
+// the units to ship per line index. Units already shipped or refunded are
+// not shippable.
+func shipmentQuantities(o *domain.Order, items []ShipmentItem) (map[int]int, error) {
+	left := func(it domain.OrderItem) int {
+		return max(it.Quantity-it.ShippedQuantity-it.RefundedQuantity, 0)
+	}
+	lineQty := make(map[int]int)
+	if len(items) == 0 {
+		for i, it := range o.Items {
+			if n := left(it); n > 0 {
+				lineQty[i] = n
+			}
+		}
+		return lineQty, nil
+	}
+	for _, req := range items {
+		need, ordered := req.Quantity, false
+		for i, it := range o.Items {
+			if it.ProductID != req.ProductID {
+				continue
+			}
+			ordered = true
+			take := min(need, left(it)-lineQty[i])
+			if take > 0 {
+				lineQty[i] += take
+				need -= take
+			}
+		}
+		if !ordered {
+			return nil, fmt.Errorf("%w: product %s is not on the order", ErrInvalidShipment, req.ProductID)
+		}
+		if need > 0 {
+			return nil, fmt.Errorf("%w: only %d of product %s left to ship", ErrInvalidShipment, req.Quantity-need, req.ProductID)
+		}
+	}
+	return lineQty, nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/repository"
+)
+
+// noTx runs transactions inline.
+type noTx struct{}
+
+func (noTx) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// shipmentOrders holds one order and records the last shipment added to it;
+// the other repository methods are not used by Ship.
+type shipmentOrders struct {
+	repository.OrderRepository
+	order   *domain.Order
+	lineQty map[int]int
+	change  *domain.StatusChange
+}
+
+func (r *shipmentOrders) GetByID(ctx context.Context, id string) (*domain.Order, error) {
+	if r.order == nil || id != r.order.ID {
+		return nil, repository.ErrNotFound
+	}
+	o := *r.order
+	o.Items = append([]domain.OrderItem(nil), r.order.Items...)
+	return &o, nil
+}
+
+func (r *shipmentOrders) AddShipment(ctx context.Context, o *domain.Order, s domain.Shipment, lineQty map[int]int, change *domain.StatusChange) error {
+	r.lineQty, r.change = lineQty, change
+	return nil
+}
+
+func TestShip(t *testing.T) {
+	type line struct {
+		product                string
+		qty, shipped, refunded int
+	}
+	tests := []struct {
+		name       string
+		status     domain.OrderStatus
+		lines      []line
+		items      []ShipmentItem
+		wantQty    map[int]int
+		wantStatus domain.OrderStatus
+		wantErr    error
+	}{
+		{"everything by default", domain.OrderPaid, []line{{"a", 2, 0, 0}, {"b", 1, 0, 0}}, nil,
+			map[int]int{0: 2, 1: 1}, domain.OrderShipped, nil},
+		{"first of several parcels", domain.OrderPaid, []line{{"a", 2, 0, 0}, {"b", 1, 0, 0}}, []ShipmentItem{{"a", 1}},
+			map[int]int{0: 1}, domain.OrderPartiallyShipped, nil},
+		{"last parcel", domain.OrderPartiallyShipped, []line{{"a", 2, 1, 0}, {"b", 1, 1, 0}}, []ShipmentItem{{"a", 1}},
+			map[int]int{0: 1}, domain.OrderShipped, nil},
+		{"another partial parcel keeps the status", domain.OrderPartiallyShipped, []line{{"a", 3, 1, 0}}, []ShipmentItem{{"a", 1}},
+			map[int]int{0: 1}, domain.OrderPartiallyShipped, nil},
+		{"refunded units are not owed", domain.OrderPartiallyRefunded, []line{{"a", 3, 0, 1}}, nil,
+			map[int]int{0: 2}, domain.OrderShipped, nil},
+		{"from processing", domain.OrderProcessing, []line{{"a", 1, 0, 0}}, nil,
+			map[int]int{0: 1}, domain.OrderShipped, nil},
+		{"product on two lines", domain.OrderPaid, []line{{"a", 1, 1, 0}, {"b", 1, 0, 0}, {"a", 2, 0, 0}}, []ShipmentItem{{"a", 2}},
+			map[int]int{2: 2}, domain.OrderPartiallyShipped, nil},
+		{"more than owed", domain.OrderPaid, []line{{"a", 2, 1, 0}}, []ShipmentItem{{"a", 2}}, nil, "", ErrInvalidShipment},
+		{"refunded units cannot ship", domain.OrderPartiallyRefunded, []line{{"a", 2, 0, 1}}, []ShipmentItem{{"a", 2}}, nil, "", ErrInvalidShipment},
+		{"not on the order", domain.OrderPaid, []line{{"a", 1, 0, 0}}, []ShipmentItem{{"c", 1}}, nil, "", ErrInvalidShipment},
+		{"nothing left", domain.OrderPartiallyRefunded, []line{{"a", 2, 1, 1}}, nil, nil, "", ErrOrderNotShippable},
+		{"unpaid", domain.OrderPending, []line{{"a", 1, 0, 0}}, nil, nil, "", ErrOrderNotShippable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &domain.Order{ID: "o1", Status: tt.status}
+			for _, l := range tt.lines {
+				o.Items = append(o.Items, domain.OrderItem{ProductID: l.product, Quantity: l.qty, ShippedQuantity: l.shipped, RefundedQuantity: l.refunded})
+			}
+			repo := &shipmentOrders{order: o}
+			svc := NewFulfillmentService(repo, noTx{})
+			sh, err := svc.Ship(context.Background(), "o1", "admin", ShipmentRequest{Carrier: "UPS", Items: tt.items})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Ship() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(repo.lineQty) != len(tt.wantQty) {
+				t.Fatalf("shipped %v, want %v", repo.lineQty, tt.wantQty)
+			}
+			for i, n := range tt.wantQty {
+				if repo.lineQty[i] != n {
+					t.Fatalf("shipped %v, want %v", repo.lineQty, tt.wantQty)
+				}
+			}
+			if len(sh.Lines) != len(tt.wantQty) {
+				t.Errorf("shipment lines = %+v, want one per shipped order line", sh.Lines)
+			}
+			switch {
+			case tt.wantStatus == tt.status:
+				if repo.change != nil {
+					t.Errorf("status change %+v, want none", repo.change)
+				}
+			case repo.change == nil:
+				t.Errorf("no status change, want %s", tt.wantStatus)
+			case repo.change.From != tt.status || repo.change.To != tt.wantStatus:
+				t.Errorf("status change %s -> %s, want %s -> %s", repo.change.From, repo.change.To, tt.status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestShipRequiresCarrier(t *testing.T) {
+	svc := NewFulfillmentService(&shipmentOrders{}, noTx{})
+	if _, err := svc.Ship(context.Background(), "o1", "admin", ShipmentRequest{Carrier: "  "}); !errors.Is(err, ErrInvalidShipment) {
+		t.Errorf("Ship() error = %v, want ErrInvalidShipment", err)
+	}
+	_, err := svc.Ship(context.Background(), "o1", "admin", ShipmentRequest{Carrier: "UPS", Items: []ShipmentItem{{"a", 0}}})
+	if !errors.Is(err, ErrInvalidQuantity) {
+		t.Errorf("Ship() error = %v, want ErrInvalidQuantity", err)
+	}
+}
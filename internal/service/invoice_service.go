@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/repository"
+)
+
+var ErrOrderNotInvoiceable = errors.New("order cannot be invoiced")
+
+type InvoiceService interface {
+	// ForOrder returns the invoice of a paid order, issuing it on first
+	// use. Orders that do not belong to a non-admin caller are reported as
+	// not found.
+	ForOrder(ctx context.Context, orderID, userID string, isAdmin bool) (*domain.Invoice, error)
+}
+
+type invoiceService struct {
+	repo    repository.InvoiceRepository
+	orders  OrderService
+	tx      repository.Transactor
+	numbers NumberSequence
+	seller  domain.Seller
+}
+
+func NewInvoiceService(r repository.InvoiceRepository, orders OrderService, tx repository.Transactor, numbers NumberSequence, seller domain.Seller) InvoiceService {
+	return &invoiceService{repo: r, orders: orders, tx: tx, numbers: numbers, seller: seller}
+}
+
+func (s *invoiceService) ForOrder(ctx context.Context, orderID, userID string, isAdmin bool) (*domain.Invoice, error) {
+	o, err := s.orders.GetForUser(ctx, orderID, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	inv, err := s.repo.GetByOrder(ctx, o.ID)
+	if err == nil {
+		return inv, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	if !invoiceable(o.Status) {
+		return nil, fmt.Errorf("%w: %s orders have no invoice", ErrOrderNotInvoiceable, o.Status)
+	}
+
+	inv = newInvoice(o, s.seller)
+	// the number is drawn in the same transaction as the insert, so a
+	// failed insert gives it back and the sequence stays gap-free
+	err = s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if inv.Number, err = s.numbers.next(ctx, inv.IssuedAt); err != nil {
+			return err
+		}
+		return s.repo.Create(ctx, inv)
+	})
+	if errors.Is(err, repository.ErrDuplicate) {
+		// issued concurrently by another request
+		return s.repo.GetByOrder(ctx, o.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// invoiceable reports whether an order in the given status has been paid
+// for and so can be invoiced. Refunded orders keep their invoice.
+func invoiceable(status domain.OrderStatus) bool {
+	switch status {
+	case domain.OrderPending, domain.OrderPaymentFailed, domain.OrderCanceled:
+		return false
+	default:
+		return validOrderStatus(status)
+	}
+}
+
+func newInvoice(o *domain.Order, seller domain.Seller) *domain.Invoice {
+	inv := &domain.Invoice{
+		OrderID:      o.ID,
+		OrderNumber:  o.Number,
+		UserID:       o.UserID,
+		IssuedAt:     time.Now().UTC(),
+		Seller:       seller,
+		BillTo:       o.BillingAddress,
+		ShipTo:       o.ShippingAddress,
+		Lines:        make([]domain.InvoiceLine, 0, len(o.Items)),
+		Adjustments:  o.Adjustments,
+		Subtotal:     o.Subtotal,
+		Discount:     o.Discount,
+		Shipping:     o.Shipping,
+		ShippingTax:  o.ShippingTax,
+		Tax:          o.Tax,
+		Total:        o.Total,
+		TaxInclusive: o.TaxInclusive,
+	}
+	for _, it := range o.Items {
+		inv.Lines = append(inv.Lines, domain.InvoiceLine{
+			Description: it.Name,
+			SKU:         it.SKU,
+			Quantity:    it.Quantity,
+			UnitPrice:   it.Price,
+			Amount:      it.Price.Mul(it.Quantity),
+			TaxRateBPS:  it.TaxRateBPS,
+			Tax:         it.Tax,
+		})
+	}
+	return inv
+}
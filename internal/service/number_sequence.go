@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/repository"
+)
+
+// Default prefixes of the numbers handed out when none is configured.
+const (
+	DefaultOrderNumberPrefix   = "ORD"
+	DefaultInvoiceNumberPrefix = "INV"
+)
+
+// NumberSequence hands out human-readable document numbers such as
+// ORD-2026-000123. Each prefix counts separately and numbering restarts
+// every calendar year (UTC).
+type NumberSequence struct {
+	Counters repository.CounterRepository
+	// Name distinguishes the counters of different document types.
+	Name   string
+	Prefix string
+	// DefaultPrefix is used when Prefix is blank.
+	DefaultPrefix string
+}
+
+// next draws the number for a document dated t. Drawn inside the
+// transaction that stores the document, numbers are unique, have no gaps and
+// increase in the order documents are committed.
+func (n NumberSequence) next(ctx context.Context, t time.Time) (string, error) {
+	// numbers are looked up case-insensitively, so they are stored upper case
+	prefix := strings.ToUpper(strings.TrimSpace(n.Prefix))
+	if prefix == "" {
+		prefix = strings.ToUpper(strings.TrimSpace(n.DefaultPrefix))
+	}
+	year := t.UTC().Year()
+	// the counter key is name:PREFIX:year, as order numbers have always
+	// been counted; changing it would restart issued sequences
+	seq, err := n.Counters.Next(ctx, fmt.Sprintf("%s:%s:%d", n.Name, prefix, year))
+	if err != nil {
+		return "", err
+	}
+	if prefix == "" {
+		return fmt.Sprintf("%d-%06d", year, seq), nil
+	}
+	return fmt.Sprintf("%s-%d-%06d", prefix, year, seq), nil
+}
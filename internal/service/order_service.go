@@ -3,46 +3,594 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/rseigha/goecomapi/internal/domain"
 	"github.com/rseigha/goecomapi/internal/repository"
 )
 
+var (
+	ErrEmptyOrder        = errors.New("order must contain items")
+	ErrInvalidQuantity   = errors.New("item quantity must be positive")
+	ErrUnknownProduct    = errors.New("product not found")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrMixedCurrency     = errors.New("order items must share one currency")
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrInvalidStatus     = errors.New("invalid order status")
+	ErrInvalidTransition = errors.New("invalid order status transition")
+	ErrInvalidFilter     = errors.New("invalid order filter")
+)
+
+// orderSortFields are the fields admin order searches may sort on.
+var orderSortFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"total":      true,
+	"status":     true,
+}
+
+const (
+	defaultOrderPageSize = 20
+	maxOrderPageSize     = 100
+	exportPageSize       = 500
+)
+
+// StockShortage describes an order line that could not be satisfied.
+type StockShortage struct {
+	ProductID string `json:"product_id"`
+	SKU       string `json:"sku"`
+	Requested int    `json:"requested"`
+	Available int    `json:"available"`
+}
+
+// InsufficientStockError lists every line of an order that exceeded the
+// available stock.
+type InsufficientStockError struct {
+	Items []StockShortage
+}
+
+func (e *InsufficientStockError) Error() string {
+	parts := make([]string, 0, len(e.Items))
+	for _, it := range e.Items {
+		parts = append(parts, fmt.Sprintf("%s (requested %d, available %d)", it.ProductID, it.Requested, it.Available))
+	}
+	return "insufficient stock: " + strings.Join(parts, ", ")
+}
+
+func (e *InsufficientStockError) Unwrap() error { return ErrInsufficientStock }
+
 type OrderService interface {
 	CreateOrder(ctx context.Context, o *domain.Order) error
 	GetByID(ctx context.Context, id string) (*domain.Order, error)
-	GetByUser(ctx context.Context, userID string) ([]*domain.Order, error)
+	GetForUser(ctx context.Context, id, userID string, isAdmin bool) (*domain.Order, error)
+	// GetByNumberForUser looks an order up by its order number, with the
+	// same ownership rules as GetForUser.
+	GetByNumberForUser(ctx context.Context, number, userID string, isAdmin bool) (*domain.Order, error)
+	GetByUser(ctx context.Context, userID string, status domain.OrderStatus, limit int, cursor string) (*domain.OrderPage, error)
+	UpdateStatus(ctx context.Context, id string, status domain.OrderStatus, actorID, reason string) (*domain.Order, error)
+	Cancel(ctx context.Context, id, userID string, isAdmin bool, reason string) (*domain.Order, error)
+	// Search fills in the default page and limit of f, or caps the limit,
+	// so callers can report the page they got.
+	Search(ctx context.Context, f *domain.OrderFilter) ([]*domain.Order, int64, error)
+	// Export calls fn for every order matching f, oldest first. Orders are
+	// read a page at a time, so memory use does not grow with the range.
+	// Sort, Limit and Page in f are ignored.
+	Export(ctx context.Context, f domain.OrderFilter, fn func(*domain.Order) error) error
+}
+
+// OrderCharges configures what is added on top of an order's subtotal.
+type OrderCharges struct {
+	// FlatShipping is charged once per order, in minor units of the order's
+	// currency.
+	FlatShipping int64
+	// TaxLocation is where orders are taxed.
+	TaxLocation domain.TaxLocation
 }
 
 type orderService struct {
-	repo repository.OrderRepository
-	// optionally productRepo to validate stock etc.
+	repo        repository.OrderRepository
+	productRepo repository.ProductRepository
+	tx          repository.Transactor
+	charges     OrderCharges
+	coupons     CouponService
+	promotions  PromotionService
+	taxes       TaxCalculator
+	shipping    ShippingService
+	users       UserService
+	numbers     NumberSequence
+	refunds     RefundService
+	// reservations holds the stock of unpaid orders for holdFor; zero
+	// holds it until the order is paid or canceled.
+	reservations repository.ReservationRepository
+	holdFor      time.Duration
 }
 
-func NewOrderService(r repository.OrderRepository) OrderService {
-	return &orderService{repo: r}
+func NewOrderService(r repository.OrderRepository, pr repository.ProductRepository, tx repository.Transactor, charges OrderCharges, coupons CouponService, promotions PromotionService, taxes TaxCalculator, shipping ShippingService, users UserService, numbers NumberSequence, refunds RefundService, reservations repository.ReservationRepository, holdFor time.Duration) OrderService {
+	return &orderService{
+		repo:        r,
+		productRepo: pr,
+		tx:          tx,
+		charges:     charges,
+		coupons:     coupons,
+		promotions:  promotions,
+		taxes:       taxes,
+		shipping:    shipping,
+		users:       users,
+		numbers:     numbers,
+		refunds:     refunds,
+
+		reservations: reservations,
+		holdFor:      holdFor,
+	}
 }
 
+// CreateOrder prices every line from the product catalog, applies active
+// promotions and the order's coupon, adds shipping and tax, and reserves the
+// ordered quantities. Pricing, stock updates, coupon redemption and the
+// insert run in one transaction, so either the whole order is placed or
+// nothing changes.
+//
+// The shipping and billing addresses are copied onto the order, so later
+// address book edits do not change it. An address that only carries an ID
+// refers to the user's address book; without a shipping address the user's
+// default address is used.
 func (s *orderService) CreateOrder(ctx context.Context, o *domain.Order) error {
 	if len(o.Items) == 0 {
-		return errors.New("order must contain items")
+		return ErrEmptyOrder
 	}
-	total := 0.0
 	for _, it := range o.Items {
-		total += it.Price * float64(it.Quantity)
+		if it.Quantity <= 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidQuantity, it.ProductID)
+		}
+	}
+	var err error
+	if o.ShippingAddress, err = s.resolveAddress(ctx, o.UserID, o.ShippingAddress); err != nil {
+		return err
+	}
+	if o.BillingAddress != nil {
+		if o.BillingAddress, err = s.resolveAddress(ctx, o.UserID, o.BillingAddress); err != nil {
+			return err
+		}
+	} else if o.ShippingAddress != nil {
+		billing := *o.ShippingAddress
+		o.BillingAddress = &billing
+	}
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		var subtotal domain.Money
+		var shortages []StockShortage
+		for i := range o.Items {
+			it := &o.Items[i]
+			p, err := s.productRepo.GetByID(ctx, it.ProductID)
+			if err != nil {
+				if errors.Is(err, repository.ErrNotFound) {
+					return fmt.Errorf("%w: %s", ErrUnknownProduct, it.ProductID)
+				}
+				return err
+			}
+			it.Name = p.Name
+			it.SKU = p.SKU
+			it.Price = p.Price
+			it.TaxClass = p.TaxClass
+			it.WeightGrams = p.WeightGrams
+			if subtotal, err = subtotal.Add(it.Price.Mul(it.Quantity)); err != nil {
+				return fmt.Errorf("%w: %v", ErrMixedCurrency, err)
+			}
+
+			if err := s.productRepo.DecrementStock(ctx, it.ProductID, it.Quantity); err != nil {
+				if !errors.Is(err, repository.ErrInsufficientStock) {
+					return err
+				}
+				shortages = append(shortages, StockShortage{
+					ProductID: it.ProductID,
+					SKU:       p.SKU,
+					Requested: it.Quantity,
+					Available: p.Stock,
+				})
+			}
+		}
+		if len(shortages) > 0 {
+			return &InsufficientStockError{Items: shortages}
+		}
+		o.ID = ""
+		o.Subtotal = subtotal
+		adjustments, err := s.promotions.Apply(ctx, orderLines(o.Items))
+		if err != nil {
+			return err
+		}
+		var coupon *domain.Coupon
+		var couponDiscount domain.Money
+		if o.CouponCode != "" {
+			c, discount, err := s.coupons.Discount(ctx, o.CouponCode, o.UserID, o.Items, subtotal)
+			if err != nil {
+				return err
+			}
+			coupon, couponDiscount, o.CouponCode = c, discount, c.Code
+			adjustments = append(adjustments, couponAdjustment(c, discount))
+		}
+		o.Adjustments = adjustments
+		o.Discount = domain.Money{Currency: subtotal.Currency}
+		for _, a := range adjustments {
+			o.Discount.Amount += a.Amount.Amount
+		}
+		// stacked discounts never make the goods cost less than nothing
+		o.Discount.Amount = min(o.Discount.Amount, subtotal.Amount)
+		if err := s.applyShipping(ctx, o); err != nil {
+			return err
+		}
+		if err := s.applyTax(ctx, o); err != nil {
+			return err
+		}
+		o.Total = domain.NewMoney(subtotal.Amount-o.Discount.Amount+o.Shipping.Amount, subtotal.Currency)
+		if !o.TaxInclusive {
+			o.Total.Amount += o.Tax.Amount
+		}
+		o.Status = domain.OrderPending
+		o.CreatedAt = time.Now().UTC()
+		if o.Number, err = s.numbers.next(ctx, o.CreatedAt); err != nil {
+			return err
+		}
+		o.UpdatedAt = o.CreatedAt
+		o.History = []domain.StatusChange{{To: domain.OrderPending, At: o.CreatedAt, ActorID: o.UserID}}
+		if s.holdFor > 0 {
+			until := o.CreatedAt.Add(s.holdFor)
+			o.ReservedUntil = &until
+		}
+		if err := s.repo.Create(ctx, o); err != nil {
+			return err
+		}
+		if err := s.reserve(ctx, o); err != nil {
+			return err
+		}
+		if coupon != nil {
+			return s.coupons.Redeem(ctx, coupon, o.UserID, o.ID, couponDiscount)
+		}
+		return nil
+	})
+}
+
+// reserve records the stock taken for o so it can be released if o is never
+// paid.
+func (s *orderService) reserve(ctx context.Context, o *domain.Order) error {
+	rv := &domain.Reservation{
+		OrderID:   o.ID,
+		UserID:    o.UserID,
+		Lines:     make([]domain.ReservationLine, 0, len(o.Items)),
+		Status:    domain.ReservationActive,
+		ExpiresAt: o.ReservedUntil,
+	}
+	for _, it := range o.Items {
+		rv.Lines = append(rv.Lines, domain.ReservationLine{ProductID: it.ProductID, SKU: it.SKU, Quantity: it.Quantity})
+	}
+	return s.reservations.Create(ctx, rv)
+}
+
+// closeReservation moves the order's active reservation to status. Orders
+// placed before reservations were tracked have none, and a reservation that
+// is already closed needs nothing more.
+func (s *orderService) closeReservation(ctx context.Context, orderID string, status domain.ReservationStatus, reason string) error {
+	err := s.reservations.Close(ctx, orderID, status, reason)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// resolveAddress returns the address to snapshot onto an order: a copy of the
+// address book entry when a carries only an ID, the user's default address
+// when a is nil, or a itself after validation.
+func (s *orderService) resolveAddress(ctx context.Context, userID string, a *domain.Address) (*domain.Address, error) {
+	if a == nil || a.ID != "" && a.Line1 == "" {
+		id := ""
+		if a != nil {
+			id = a.ID
+		}
+		book, err := s.users.ResolveAddress(ctx, userID, id)
+		if book != nil {
+			book.IsDefault = false
+		}
+		return book, err
+	}
+	out := *a
+	out.ID, out.Label, out.IsDefault = "", "", false
+	if err := NormalizeAddress(&out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// applyShipping prices the order's shipping method, or charges the flat fee
+// when none was chosen. Free-over thresholds look at the discounted goods.
+func (s *orderService) applyShipping(ctx context.Context, o *domain.Order) error {
+	if o.ShippingMethod == "" {
+		o.Shipping = domain.NewMoney(s.charges.FlatShipping, o.Subtotal.Currency)
+		return nil
+	}
+	goods := domain.NewMoney(o.Subtotal.Amount-o.Discount.Amount, o.Subtotal.Currency)
+	m, cost, err := s.shipping.Quote(ctx, o.ShippingMethod, o.ShippingAddress, o.Items, goods)
+	if err != nil {
+		return err
+	}
+	o.ShippingMethod = m.Code
+	o.Shipping = cost
+	return nil
+}
+
+// applyTax fills the per-line, shipping and total tax of a priced order. Tax
+// is charged on what the customer actually pays for each line, so the
+// order's discount is spread over the lines first.
+func (s *orderService) applyTax(ctx context.Context, o *domain.Order) error {
+	o.TaxLocation = s.charges.TaxLocation
+	if o.ShippingAddress != nil {
+		o.TaxLocation = o.ShippingAddress.TaxLocation()
+	}
+	net := allocateDiscount(o)
+	req := TaxRequest{Location: o.TaxLocation, Shipping: o.Shipping, Lines: make([]TaxLine, len(o.Items))}
+	for i, it := range o.Items {
+		req.Lines[i] = TaxLine{ProductID: it.ProductID, TaxClass: it.TaxClass, Amount: net[i]}
+	}
+	res, err := s.taxes.Calculate(ctx, req)
+	if err != nil {
+		return err
+	}
+	if len(res.Lines) != len(o.Items) {
+		return fmt.Errorf("tax calculator returned %d lines for %d items", len(res.Lines), len(o.Items))
+	}
+	o.Tax = domain.Money{Currency: o.Subtotal.Currency}
+	for i := range o.Items {
+		o.Items[i].Tax = res.Lines[i].Tax
+		o.Items[i].TaxRateBPS = res.Lines[i].RateBPS
+		o.Tax.Amount += res.Lines[i].Tax.Amount
+	}
+	o.ShippingTax = domain.NewMoney(res.Shipping.Amount, o.Subtotal.Currency)
+	o.Tax.Amount += res.Shipping.Amount
+	o.TaxInclusive = res.Inclusive
+	return nil
+}
+
+// allocateDiscount spreads the order's discount over its lines in proportion
+// to their value and returns what is left to pay on each line. Rounding
+// leftovers go to the last line so the lines add up to the discount exactly.
+func allocateDiscount(o *domain.Order) []domain.Money {
+	net := make([]domain.Money, len(o.Items))
+	remaining := o.Discount.Amount
+	for i, it := range o.Items {
+		gross := it.Price.Mul(it.Quantity)
+		share := remaining
+		if i < len(o.Items)-1 && o.Subtotal.Amount > 0 {
+			share = min(o.Discount.MulRatio(gross.Amount, o.Subtotal.Amount).Amount, remaining)
+		}
+		remaining -= share
+		net[i] = domain.NewMoney(gross.Amount-share, gross.Currency)
 	}
-	o.Total = total
-	o.Status = domain.OrderPending
-	o.CreatedAt = time.Now().UTC()
-	o.UpdatedAt = o.CreatedAt
-	return s.repo.Create(ctx, o)
+	return net
 }
 
 func (s *orderService) GetByID(ctx context.Context, id string) (*domain.Order, error) {
-	return s.repo.GetByID(ctx, id)
+	o, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	return o, err
+}
+
+// GetForUser returns the order if the caller owns it or is an admin. Orders
+// belonging to someone else are reported as not found so that order IDs
+// cannot be probed.
+func (s *orderService) GetForUser(ctx context.Context, id, userID string, isAdmin bool) (*domain.Order, error) {
+	o, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && o.UserID != userID {
+		return nil, ErrOrderNotFound
+	}
+	return o, nil
+}
+
+func (s *orderService) GetByNumberForUser(ctx context.Context, number, userID string, isAdmin bool) (*domain.Order, error) {
+	o, err := s.repo.GetByNumber(ctx, strings.ToUpper(strings.TrimSpace(number)))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && o.UserID != userID {
+		return nil, ErrOrderNotFound
+	}
+	return o, nil
 }
 
-func (s *orderService) GetByUser(ctx context.Context, userID string) ([]*domain.Order, error) {
-	return s.repo.GetByUserID(ctx, userID)
+// GetByUser returns one page of the user's order history, newest first.
+func (s *orderService) GetByUser(ctx context.Context, userID string, status domain.OrderStatus, limit int, cursor string) (*domain.OrderPage, error) {
+	if status != "" && !validOrderStatus(status) {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidFilter, status)
+	}
+	if limit > maxOrderPageSize {
+		limit = maxOrderPageSize
+	}
+	page, err := s.repo.GetByUserID(ctx, userID, status, limit, cursor)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		return nil, fmt.Errorf("%w: invalid cursor", ErrInvalidFilter)
+	}
+	return page, err
+}
+
+// UpdateStatus moves an order along its lifecycle, rejecting any move that is
+// not listed in orderTransitions.
+func (s *orderService) UpdateStatus(ctx context.Context, id string, status domain.OrderStatus, actorID, reason string) (*domain.Order, error) {
+	if !validOrderStatus(status) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidStatus, status)
+	}
+	if status == domain.OrderCanceled {
+		// cancellation restocks items, so it has its own entry point
+		return nil, fmt.Errorf("%w: use the cancel endpoint to cancel orders", ErrInvalidTransition)
+	}
+	if status == domain.OrderRefunded || status == domain.OrderPartiallyRefunded {
+		// refunds move money through the payment provider
+		return nil, fmt.Errorf("%w: use the refunds endpoint to refund orders", ErrInvalidTransition)
+	}
+	if status == domain.OrderPartiallyShipped || status == domain.OrderShipped {
+		// follows from the recorded shipments, which returns rely on
+		return nil, fmt.Errorf("%w: use the shipments endpoint to ship orders", ErrInvalidTransition)
+	}
+	o, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !canTransition(o.Status, status) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, o.Status, status)
+	}
+	change := domain.StatusChange{
+		From:    o.Status,
+		To:      status,
+		At:      time.Now().UTC(),
+		ActorID: actorID,
+		Reason:  reason,
+	}
+	err = s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdateStatus(ctx, id, change); err != nil {
+			return err
+		}
+		if status == domain.OrderPaid {
+			// paid units are sold; committing in the same transaction
+			// keeps the expiry sweep from canceling the order
+			return s.closeReservation(ctx, id, domain.ReservationCommitted, "")
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			// the order changed status between the read and the update
+			return nil, fmt.Errorf("%w: order was modified concurrently", ErrInvalidTransition)
+		}
+		return nil, err
+	}
+	o.Status = status
+	o.UpdatedAt = change.At
+	o.History = append(o.History, change)
+	return o, nil
+}
+
+// Cancel cancels an order and returns its items to stock in one transaction.
+// Orders that do not belong to a non-admin caller are reported as not found.
+// The captured payment of a paid order is then refunded in full; if the
+// provider rejects the refund the order stays canceled and the refund can be
+// retried through the refunds endpoint.
+func (s *orderService) Cancel(ctx context.Context, id, userID string, isAdmin bool, reason string) (*domain.Order, error) {
+	var out *domain.Order
+	paid := false
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		o, err := s.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !isAdmin && o.UserID != userID {
+			return ErrOrderNotFound
+		}
+		if !canCancel(o.Status, isAdmin) {
+			return fmt.Errorf("%w: %s orders cannot be canceled", ErrInvalidTransition, o.Status)
+		}
+		paid = o.Status == domain.OrderPaid || o.Status == domain.OrderProcessing
+		change := domain.StatusChange{
+			From:    o.Status,
+			To:      domain.OrderCanceled,
+			At:      time.Now().UTC(),
+			ActorID: userID,
+			Reason:  reason,
+		}
+		if err := s.repo.Cancel(ctx, id, change); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return fmt.Errorf("%w: order was modified concurrently", ErrInvalidTransition)
+			}
+			return err
+		}
+		// releasing the reservation puts the held units back into Stock
+		for _, it := range o.Items {
+			err := s.productRepo.IncrementStock(ctx, it.ProductID, it.Quantity)
+			// products deleted since the order was placed have nothing to restock
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return err
+			}
+		}
+		if err := s.closeReservation(ctx, id, domain.ReservationReleased, "order canceled"); err != nil {
+			return err
+		}
+		if o.CouponCode != "" {
+			if err := s.coupons.Release(ctx, o.ID); err != nil {
+				return err
+			}
+		}
+		o.Status = domain.OrderCanceled
+		o.CanceledBy = userID
+		o.CancelReason = reason
+		o.CanceledAt = &change.At
+		o.UpdatedAt = change.At
+		o.History = append(o.History, change)
+		out = o
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if paid {
+		// the items went back to stock with the cancellation
+		_, err := s.refunds.Refund(ctx, id, userID, RefundRequest{Reason: "order canceled"})
+		if err != nil && !errors.Is(err, errNoCapturedPayment) {
+			return nil, fmt.Errorf("order canceled but not refunded: %w", err)
+		}
+	}
+	return out, nil
+}
+
+// Search validates f and returns one page of matching orders plus the total
+// number of matches.
+func (s *orderService) Search(ctx context.Context, f *domain.OrderFilter) ([]*domain.Order, int64, error) {
+	if err := validateOrderFilter(f); err != nil {
+		return nil, 0, err
+	}
+	if f.Sort != "" && !orderSortFields[strings.TrimPrefix(f.Sort, "-")] {
+		return nil, 0, fmt.Errorf("%w: cannot sort by %q", ErrInvalidFilter, f.Sort)
+	}
+	switch {
+	case f.Limit <= 0:
+		f.Limit = defaultOrderPageSize
+	case f.Limit > maxOrderPageSize:
+		f.Limit = maxOrderPageSize
+	}
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	return s.repo.Search(ctx, *f)
+}
+
+func (s *orderService) Export(ctx context.Context, f domain.OrderFilter, fn func(*domain.Order) error) error {
+	if err := validateOrderFilter(&f); err != nil {
+		return err
+	}
+	return repository.EachOrder(ctx, s.repo, f, exportPageSize, fn)
+}
+
+// validateOrderFilter checks the constraints shared by order searches and
+// exports.
+func validateOrderFilter(f *domain.OrderFilter) error {
+	if f.Status != "" && !validOrderStatus(f.Status) {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidFilter, f.Status)
+	}
+	if f.CreatedFrom != nil && f.CreatedTo != nil && !f.CreatedFrom.Before(*f.CreatedTo) {
+		return fmt.Errorf("%w: created_from must be before created_to", ErrInvalidFilter)
+	}
+	if f.MinTotal != nil && f.MaxTotal != nil && *f.MinTotal > *f.MaxTotal {
+		return fmt.Errorf("%w: min_total must not exceed max_total", ErrInvalidFilter)
+	}
+	if (f.MinTotal != nil || f.MaxTotal != nil) && f.Currency == "" {
+		// amounts in different currencies are not comparable
+		f.Currency = domain.DefaultCurrency
+	}
+	if f.Currency != "" && !domain.ValidCurrency(f.Currency) {
+		return fmt.Errorf("%w: invalid currency %q", ErrInvalidFilter, f.Currency)
+	}
+	return nil
 }
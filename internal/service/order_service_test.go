@@ -0,0 +1,53 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+)
+
+func TestAllocateDiscount(t *testing.T) {
+	item := func(qty int, price int64) domain.OrderItem {
+		return domain.OrderItem{Quantity: qty, Price: domain.NewMoney(price, "USD")}
+	}
+	tests := []struct {
+		name     string
+		items    []domain.OrderItem
+		discount int64
+		want     []int64
+	}{
+		{"no discount", []domain.OrderItem{item(1, 1000), item(2, 500)}, 0, []int64{1000, 1000}},
+		// 33.33 each; the last line takes the leftover cent
+		{"cents split evenly", []domain.OrderItem{item(1, 1000), item(1, 1000), item(1, 1000)}, 100, []int64{967, 967, 966}},
+		{"proportional to line totals", []domain.OrderItem{item(3, 1000), item(1, 1000)}, 400, []int64{2700, 900}},
+		// 0.33 rounds to nothing, so the last line carries the whole cent
+		{"single cent", []domain.OrderItem{item(1, 1000), item(1, 1000), item(1, 1000)}, 1, []int64{1000, 1000, 999}},
+		// 0.67 rounds up on the first line, leaving nothing for the last
+		{"rounding up never overshoots", []domain.OrderItem{item(1, 1), item(1, 1), item(1, 1)}, 2, []int64{0, 0, 1}},
+		{"whole subtotal", []domain.OrderItem{item(2, 333), item(1, 1)}, 667, []int64{0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &domain.Order{Items: tt.items, Discount: domain.NewMoney(tt.discount, "USD")}
+			for _, it := range tt.items {
+				o.Subtotal.Amount += it.Price.Mul(it.Quantity).Amount
+			}
+			o.Subtotal.Currency = "USD"
+
+			net := allocateDiscount(o)
+			if len(net) != len(tt.want) {
+				t.Fatalf("allocateDiscount() = %v, want %v", net, tt.want)
+			}
+			var total int64
+			for i, m := range net {
+				if m.Amount != tt.want[i] || m.Currency != "USD" {
+					t.Errorf("line %d = %v, want %d USD", i, m, tt.want[i])
+				}
+				total += m.Amount
+			}
+			if total != o.Subtotal.Amount-tt.discount {
+				t.Errorf("lines sum to %d, want %d", total, o.Subtotal.Amount-tt.discount)
+			}
+		})
+	}
+}
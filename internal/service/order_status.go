@@ -0,0 +1,59 @@
+package service
+
+import "github.com/rseigha/goecomapi/internal/domain"
+
+// orderTransitions lists, for every known status, the statuses an order may
+// move to next. Statuses with no entries are terminal.
+var orderTransitions = map[domain.OrderStatus][]domain.OrderStatus{
+	domain.OrderPending:       {domain.OrderPaid, domain.OrderPaymentFailed, domain.OrderCanceled},
+	domain.OrderPaymentFailed: {domain.OrderPaid, domain.OrderCanceled},
+	domain.OrderPaid:          {domain.OrderProcessing, domain.OrderPartiallyShipped, domain.OrderShipped, domain.OrderCanceled, domain.OrderPartiallyRefunded, domain.OrderRefunded},
+	domain.OrderProcessing:    {domain.OrderPartiallyShipped, domain.OrderShipped, domain.OrderCanceled, domain.OrderPartiallyRefunded, domain.OrderRefunded},
+	domain.OrderShipped:       {domain.OrderDelivered, domain.OrderPartiallyRefunded, domain.OrderRefunded},
+	domain.OrderDelivered:     {domain.OrderPartiallyRefunded, domain.OrderRefunded},
+	// a partially shipped order waits for its remaining shipments
+	domain.OrderPartiallyShipped: {domain.OrderShipped, domain.OrderPartiallyRefunded, domain.OrderRefunded},
+	// a partially refunded order can still be fulfilled or refunded again
+	domain.OrderPartiallyRefunded: {domain.OrderPartiallyShipped, domain.OrderShipped, domain.OrderDelivered, domain.OrderPartiallyRefunded, domain.OrderRefunded},
+	domain.OrderCanceled:          {},
+	domain.OrderRefunded:          {},
+}
+
+func validOrderStatus(s domain.OrderStatus) bool {
+	_, ok := orderTransitions[s]
+	return ok
+}
+
+func canTransition(from, to domain.OrderStatus) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// canShip reports whether shipments may be recorded against an order in the
+// given status.
+func canShip(status domain.OrderStatus) bool {
+	switch status {
+	case domain.OrderPaid, domain.OrderProcessing, domain.OrderPartiallyShipped, domain.OrderPartiallyRefunded:
+		return true
+	default:
+		return false
+	}
+}
+
+// canCancel reports whether an order in the given status may be canceled.
+// Customers can only cancel before fulfillment starts; admins can cancel up
+// to the point the order ships.
+func canCancel(status domain.OrderStatus, isAdmin bool) bool {
+	switch status {
+	case domain.OrderPending, domain.OrderPaymentFailed, domain.OrderPaid:
+		return true
+	case domain.OrderProcessing:
+		return isAdmin
+	default:
+		return false
+	}
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/repository"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrUnknownProvider  = errors.New("unknown payment provider")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrUnknownPayment   = errors.New("payment not found")
+	ErrOrderNotPayable  = errors.New("order is not awaiting payment")
+)
+
+// PaymentIntent is a provider's handle on a payment that is yet to be
+// confirmed by the customer.
+type PaymentIntent struct {
+	ProviderRef  string
+	ClientSecret string
+}
+
+// PaymentProvider is implemented by each payment gateway integration.
+type PaymentProvider interface {
+	Name() string
+	// CreateIntent asks the provider to prepare a payment of amount. The
+	// customer completes it client-side using the returned client secret.
+	CreateIntent(ctx context.Context, orderID string, amount domain.Money) (*PaymentIntent, error)
+	// Capture collects an authorized payment.
+	Capture(ctx context.Context, providerRef string, amount domain.Money) error
+	// Refund returns amount of a captured payment and returns the
+	// provider's refund reference.
+	Refund(ctx context.Context, providerRef string, amount domain.Money) (string, error)
+	// VerifyWebhook checks the signature of a webhook request and decodes
+	// it. It returns ErrInvalidSignature for requests that cannot be trusted.
+	VerifyWebhook(payload []byte, header http.Header) (*domain.PaymentEvent, error)
+}
+
+type PaymentService interface {
+	StartPayment(ctx context.Context, orderID, userID, provider string) (*domain.Payment, error)
+	HandleWebhook(ctx context.Context, provider string, payload []byte, header http.Header) error
+	ListByOrder(ctx context.Context, orderID, userID string, isAdmin bool) ([]*domain.Payment, error)
+}
+
+type paymentService struct {
+	repo      repository.PaymentRepository
+	orders    OrderService
+	providers map[string]PaymentProvider
+	logger    *zap.Logger
+}
+
+func NewPaymentService(r repository.PaymentRepository, o OrderService, providers []PaymentProvider, logger *zap.Logger) PaymentService {
+	byName := make(map[string]PaymentProvider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+	return &paymentService{repo: r, orders: o, providers: byName, logger: logger}
+}
+
+func (s *paymentService) provider(name string) (PaymentProvider, error) {
+	p, ok := s.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, name)
+	}
+	return p, nil
+}
+
+// StartPayment creates a payment intent for the order's total. Only the
+// order's owner can pay for it, and only while it awaits payment.
+func (s *paymentService) StartPayment(ctx context.Context, orderID, userID, providerName string) (*domain.Payment, error) {
+	p, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	o, err := s.orders.GetForUser(ctx, orderID, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	if o.Status != domain.OrderPending && o.Status != domain.OrderPaymentFailed {
+		return nil, fmt.Errorf("%w: order is %s", ErrOrderNotPayable, o.Status)
+	}
+	intent, err := p.CreateIntent(ctx, o.ID, o.Total)
+	if err != nil {
+		return nil, err
+	}
+	pay := &domain.Payment{
+		OrderID:     o.ID,
+		UserID:      userID,
+		Provider:    p.Name(),
+		ProviderRef: intent.ProviderRef,
+		Amount:      o.Total,
+		Status:      domain.PaymentPending,
+	}
+	if err := s.repo.Create(ctx, pay); err != nil {
+		return nil, err
+	}
+	pay.ClientSecret = intent.ClientSecret
+	return pay, nil
+}
+
+// HandleWebhook applies a verified provider event. Authorized payments are
+// captured and their order marked paid; failed payments mark the order
+// payment_failed so the customer can try again. Providers retry webhooks, so
+// events for payments that already moved on are acknowledged and ignored.
+func (s *paymentService) HandleWebhook(ctx context.Context, providerName string, payload []byte, header http.Header) error {
+	p, err := s.provider(providerName)
+	if err != nil {
+		return err
+	}
+	ev, err := p.VerifyWebhook(payload, header)
+	if err != nil {
+		return err
+	}
+	pay, err := s.repo.GetByProviderRef(ctx, p.Name(), ev.ProviderRef)
+	if errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrUnknownPayment, ev.ProviderRef)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch ev.Type {
+	case domain.PaymentEventAuthorized:
+		return s.capture(ctx, p, pay)
+	case domain.PaymentEventFailed:
+		return s.fail(ctx, pay, ev.FailureReason)
+	default:
+		// providers send many event types; acknowledge the ones we ignore
+		s.logger.Debug("ignoring payment event", zap.String("provider", p.Name()), zap.String("type", string(ev.Type)))
+		return nil
+	}
+}
+
+func (s *paymentService) capture(ctx context.Context, p PaymentProvider, pay *domain.Payment) error {
+	switch pay.Status {
+	case domain.PaymentPending:
+	case domain.PaymentCaptured:
+		// a retry after the order update failed last time
+		return s.settle(ctx, p, pay)
+	default:
+		return nil
+	}
+	if err := s.repo.UpdateStatus(ctx, pay.ID, domain.PaymentPending, domain.PaymentAuthorized, ""); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			// a concurrent delivery of the same event got here first
+			return nil
+		}
+		return err
+	}
+	if err := p.Capture(ctx, pay.ProviderRef, pay.Amount); err != nil {
+		// put the payment back so the provider's retry can capture it
+		if rerr := s.repo.UpdateStatus(ctx, pay.ID, domain.PaymentAuthorized, domain.PaymentPending, ""); rerr != nil {
+			s.logger.Error("could not reset payment after failed capture", zap.String("payment_id", pay.ID), zap.Error(rerr))
+		}
+		return err
+	}
+	if err := s.repo.UpdateStatus(ctx, pay.ID, domain.PaymentAuthorized, domain.PaymentCaptured, ""); err != nil {
+		return err
+	}
+	return s.settle(ctx, p, pay)
+}
+
+// settle marks the order of a captured payment as paid.
+func (s *paymentService) settle(ctx context.Context, p PaymentProvider, pay *domain.Payment) error {
+	o, err := s.orders.GetByID(ctx, pay.OrderID)
+	if err != nil {
+		return err
+	}
+	switch o.Status {
+	case domain.OrderPending, domain.OrderPaymentFailed:
+		_, err := s.orders.UpdateStatus(ctx, o.ID, domain.OrderPaid, "", "payment "+pay.ID+" captured")
+		return err
+	case domain.OrderCanceled:
+		// the order was canceled while the customer was paying; give the
+		// money back rather than keep it against a dead order
+		return s.refundUnneeded(ctx, p, pay, "order canceled")
+	}
+	// the order is already paid; refund this payment unless it is the one
+	// that paid for it
+	payments, err := s.repo.ListByOrder(ctx, o.ID)
+	if err != nil {
+		return err
+	}
+	for _, other := range payments {
+		if other.ID != pay.ID && other.Status == domain.PaymentCaptured {
+			return s.refundUnneeded(ctx, p, pay, "order already paid")
+		}
+	}
+	return nil
+}
+
+func (s *paymentService) refundUnneeded(ctx context.Context, p PaymentProvider, pay *domain.Payment, reason string) error {
+	s.logger.Warn("refunding unneeded payment",
+		zap.String("order_id", pay.OrderID), zap.String("payment_id", pay.ID), zap.String("reason", reason))
+	if _, err := p.Refund(ctx, pay.ProviderRef, pay.Amount); err != nil {
+		return err
+	}
+	return s.repo.UpdateStatus(ctx, pay.ID, domain.PaymentCaptured, domain.PaymentRefunded, reason)
+}
+
+func (s *paymentService) fail(ctx context.Context, pay *domain.Payment, reason string) error {
+	if pay.Status != domain.PaymentPending {
+		return nil
+	}
+	if err := s.repo.UpdateStatus(ctx, pay.ID, domain.PaymentPending, domain.PaymentFailed, reason); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	_, err := s.orders.UpdateStatus(ctx, pay.OrderID, domain.OrderPaymentFailed, "", "payment "+pay.ID+" failed: "+reason)
+	if errors.Is(err, ErrInvalidTransition) {
+		// already failed once, or canceled; nothing to move
+		return nil
+	}
+	return err
+}
+
+// ListByOrder returns the payments made against an order, newest first.
+func (s *paymentService) ListByOrder(ctx context.Context, orderID, userID string, isAdmin bool) ([]*domain.Payment, error) {
+	if _, err := s.orders.GetForUser(ctx, orderID, userID, isAdmin); err != nil {
+		return nil, err
+	}
+	return s.repo.ListByOrder(ctx, orderID)
+}
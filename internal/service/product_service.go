@@ -2,15 +2,26 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/rseigha/goecomapi/internal/domain"
 	"github.com/rseigha/goecomapi/internal/repository"
 )
 
+var (
+	ErrInvalidPrice           = errors.New("invalid price")
+	ErrInvalidStockAdjustment = errors.New("invalid stock adjustment")
+)
+
 type ProductService interface {
 	Create(ctx context.Context, p *domain.Product) error
 	GetByID(ctx context.Context, id string) (*domain.Product, error)
+	// Update changes a product's catalog details; its stock is not touched.
 	Update(ctx context.Context, p *domain.Product) error
+	// AdjustStock adds delta units to the product's available stock, or
+	// removes them when delta is negative, and returns the updated product.
+	AdjustStock(ctx context.Context, id string, delta int) (*domain.Product, error)
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, limit, page int) ([]*domain.Product, int64, error)
 }
@@ -24,6 +35,9 @@ func NewProductService(r repository.ProductRepository) ProductService {
 }
 
 func (s *productService) Create(ctx context.Context, p *domain.Product) error {
+	if err := normalizePrice(&p.Price); err != nil {
+		return err
+	}
 	return s.repo.Create(ctx, p)
 }
 
@@ -32,7 +46,49 @@ func (s *productService) GetByID(ctx context.Context, id string) (*domain.Produc
 }
 
 func (s *productService) Update(ctx context.Context, p *domain.Product) error {
-	return s.repo.Update(ctx, p)
+	if err := normalizePrice(&p.Price); err != nil {
+		return err
+	}
+	if err := s.repo.Update(ctx, p); err != nil {
+		return err
+	}
+	// report the stock as stored, not as the client sent it
+	cur, err := s.repo.GetByID(ctx, p.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrUnknownProduct, p.ID)
+	}
+	if err != nil {
+		return err
+	}
+	*p = *cur
+	return nil
+}
+
+// AdjustStock changes stock by a relative amount, so deliveries and
+// write-offs never overwrite units that orders took or returned meanwhile.
+func (s *productService) AdjustStock(ctx context.Context, id string, delta int) (*domain.Product, error) {
+	if delta == 0 {
+		return nil, fmt.Errorf("%w: delta must not be zero", ErrInvalidStockAdjustment)
+	}
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownProduct, id)
+		}
+		return nil, err
+	}
+	var err error
+	if delta > 0 {
+		err = s.repo.IncrementStock(ctx, id, delta)
+	} else {
+		err = s.repo.DecrementStock(ctx, id, -delta)
+	}
+	if errors.Is(err, repository.ErrInsufficientStock) {
+		return nil, fmt.Errorf("%w: cannot remove %d units", ErrInsufficientStock, -delta)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
 }
 
 func (s *productService) Delete(ctx context.Context, id string) error {
@@ -42,3 +98,18 @@ func (s *productService) Delete(ctx context.Context, id string) error {
 func (s *productService) List(ctx context.Context, limit, page int) ([]*domain.Product, int64, error) {
 	return s.repo.List(ctx, limit, page)
 }
+
+// normalizePrice fills in the default currency and rejects negative or
+// malformed prices.
+func normalizePrice(m *domain.Money) error {
+	if m.Currency == "" {
+		m.Currency = domain.DefaultCurrency
+	}
+	if !domain.ValidCurrency(m.Currency) {
+		return fmt.Errorf("%w: %v %q", ErrInvalidPrice, domain.ErrInvalidCurrency, m.Currency)
+	}
+	if m.Amount < 0 {
+		return fmt.Errorf("%w: amount must not be negative", ErrInvalidPrice)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/promotion"
+	"github.com/rseigha/goecomapi/internal/repository"
+)
+
+var (
+	ErrPromotionNotFound = errors.New("promotion not found")
+	ErrInvalidPromotion  = errors.New("invalid promotion")
+)
+
+type PromotionService interface {
+	Create(ctx context.Context, p *domain.Promotion) error
+	GetByID(ctx context.Context, id string) (*domain.Promotion, error)
+	Update(ctx context.Context, p *domain.Promotion) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, limit, page int) ([]*domain.Promotion, int64, error)
+
+	// Apply evaluates the active promotions against lines and returns the
+	// adjustments they produce.
+	Apply(ctx context.Context, lines []promotion.Line) ([]domain.Adjustment, error)
+}
+
+type promotionService struct {
+	repo repository.PromotionRepository
+}
+
+func NewPromotionService(r repository.PromotionRepository) PromotionService {
+	return &promotionService{repo: r}
+}
+
+func (s *promotionService) Create(ctx context.Context, p *domain.Promotion) error {
+	if err := validatePromotion(p); err != nil {
+		return err
+	}
+	return s.repo.Create(ctx, p)
+}
+
+func (s *promotionService) GetByID(ctx context.Context, id string) (*domain.Promotion, error) {
+	p, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrPromotionNotFound
+	}
+	return p, err
+}
+
+func (s *promotionService) Update(ctx context.Context, p *domain.Promotion) error {
+	if err := validatePromotion(p); err != nil {
+		return err
+	}
+	err := s.repo.Update(ctx, p)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrPromotionNotFound
+	}
+	return err
+}
+
+func (s *promotionService) Delete(ctx context.Context, id string) error {
+	err := s.repo.Delete(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrPromotionNotFound
+	}
+	return err
+}
+
+func (s *promotionService) List(ctx context.Context, limit, page int) ([]*domain.Promotion, int64, error) {
+	return s.repo.List(ctx, limit, page)
+}
+
+func (s *promotionService) Apply(ctx context.Context, lines []promotion.Line) ([]domain.Adjustment, error) {
+	now := time.Now().UTC()
+	promos, err := s.repo.ListActive(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	return promotion.Evaluate(promos, lines, now), nil
+}
+
+// validatePromotion fills in default currencies and checks the rule.
+func validatePromotion(p *domain.Promotion) error {
+	for i := range p.Tiers {
+		if err := normalizePrice(&p.Tiers[i].Threshold); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPromotion, err)
+		}
+		if err := normalizePrice(&p.Tiers[i].AmountOff); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPromotion, err)
+		}
+	}
+	if err := normalizePrice(&p.BundlePrice); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPromotion, err)
+	}
+	if err := promotion.Validate(p); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPromotion, err)
+	}
+	return nil
+}
+
+// orderLines converts priced order items for the promotion engine.
+func orderLines(items []domain.OrderItem) []promotion.Line {
+	lines := make([]promotion.Line, 0, len(items))
+	for _, it := range items {
+		lines = append(lines, promotion.Line{ProductID: it.ProductID, Name: it.Name, Quantity: it.Quantity, UnitPrice: it.Price})
+	}
+	return lines
+}
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/repository"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrOrderNotRefundable    = errors.New("order cannot be refunded")
+	ErrInvalidRefund         = errors.New("invalid refund")
+	ErrRefundExceedsCaptured = errors.New("refund exceeds the captured amount")
+	ErrRefundFailed          = errors.New("payment provider rejected the refund")
+
+	errNoCapturedPayment = errors.New("no captured payment")
+)
+
+// RefundItem selects units of one ordered product to refund.
+type RefundItem struct {
+	ProductID string
+	Quantity  int
+}
+
+// RefundRequest describes a refund. With no Items, everything still
+// refundable on the order is refunded, shipping included. Restock puts the
+// refunded units back into stock.
+type RefundRequest struct {
+	Items   []RefundItem
+	Restock bool
+	Reason  string
+}
+
+type RefundService interface {
+	Refund(ctx context.Context, orderID, actorID string, req RefundRequest) (*domain.Refund, error)
+	ListByOrder(ctx context.Context, orderID string) ([]*domain.Refund, error)
+}
+
+type refundService struct {
+	repo        repository.RefundRepository
+	orderRepo   repository.OrderRepository
+	paymentRepo repository.PaymentRepository
+	productRepo repository.ProductRepository
+	tx          repository.Transactor
+	providers   map[string]PaymentProvider
+	logger      *zap.Logger
+}
+
+func NewRefundService(r repository.RefundRepository, or repository.OrderRepository, pr repository.PaymentRepository, prod repository.ProductRepository, tx repository.Transactor, providers []PaymentProvider, logger *zap.Logger) RefundService {
+	byName := make(map[string]PaymentProvider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+	return &refundService{
+		repo:        r,
+		orderRepo:   or,
+		paymentRepo: pr,
+		productRepo: prod,
+		tx:          tx,
+		providers:   byName,
+		logger:      logger,
+	}
+}
+
+// pendingRefund is what the first phase of a refund reserved.
+type pendingRefund struct {
+	refund  *domain.Refund
+	payment *domain.Payment
+	lineQty map[int]int
+	// full is set when the refund uses up the rest of the payment
+	full bool
+}
+
+// Refund gives money back on a paid order through the provider that
+// captured it. The amount and quantities are reserved on the order and
+// payment before the provider is called, so concurrent refunds cannot
+// together exceed what was captured; if the provider rejects the refund the
+// reservation is released again.
+func (s *refundService) Refund(ctx context.Context, orderID, actorID string, req RefundRequest) (*domain.Refund, error) {
+	for _, it := range req.Items {
+		if it.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidQuantity, it.ProductID)
+		}
+	}
+	var pr *pendingRefund
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		pr, err = s.reserve(ctx, orderID, actorID, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rf := pr.refund
+	ref, err := s.providers[rf.Provider].Refund(ctx, pr.payment.ProviderRef, rf.Amount)
+	if err != nil {
+		if rerr := s.tx.WithTransaction(ctx, func(ctx context.Context) error { return s.release(ctx, pr, err) }); rerr != nil {
+			s.logger.Error("could not release rejected refund", zap.String("refund_id", rf.ID), zap.Error(rerr))
+		}
+		return nil, fmt.Errorf("%w: %v", ErrRefundFailed, err)
+	}
+	if err := s.tx.WithTransaction(ctx, func(ctx context.Context) error { return s.complete(ctx, pr, ref, actorID, req.Restock) }); err != nil {
+		// the money has left; the pending refund must be reconciled by hand
+		s.logger.Error("refund issued but not recorded",
+			zap.String("refund_id", rf.ID), zap.String("provider_ref", ref), zap.Error(err))
+		return nil, err
+	}
+	rf.Status = domain.RefundSucceeded
+	rf.ProviderRef = ref
+	return rf, nil
+}
+
+func (s *refundService) reserve(ctx context.Context, orderID, actorID string, req RefundRequest) (*pendingRefund, error) {
+	o, err := s.orderRepo.GetByID(ctx, orderID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if o.Status == domain.OrderCanceled {
+		// canceled orders stay canceled, but money captured against one
+		// (refunded by the cancellation, or paid while it was being
+		// canceled) can still go back; its items were restocked by the
+		// cancellation
+		if req.Restock {
+			return nil, fmt.Errorf("%w: canceled orders were restocked when canceled", ErrInvalidRefund)
+		}
+	} else if !canTransition(o.Status, domain.OrderPartiallyRefunded) {
+		return nil, fmt.Errorf("%w: %s orders cannot be refunded", ErrOrderNotRefundable, o.Status)
+	}
+	pay, err := s.capturedPayment(ctx, o.ID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.providers[pay.Provider]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, pay.Provider)
+	}
+	refundable, err := pay.Amount.Sub(pay.Refunded)
+	if err != nil {
+		return nil, err
+	}
+
+	lineQty, err := refundQuantities(o, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	lines, amount, full, err := refundAmount(o, lineQty, refundable, len(req.Items) == 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.orderRepo.AddRefund(ctx, o.ID, lineQty, amount); err != nil {
+		return nil, err
+	}
+	if err := s.paymentRepo.AddRefund(ctx, pay.ID, amount); err != nil {
+		return nil, err
+	}
+	rf := &domain.Refund{
+		OrderID:   o.ID,
+		PaymentID: pay.ID,
+		Provider:  pay.Provider,
+		Amount:    amount,
+		Lines:     lines,
+		Restock:   req.Restock,
+		Reason:    req.Reason,
+		ActorID:   actorID,
+		Status:    domain.RefundPending,
+	}
+	if err := s.repo.Create(ctx, rf); err != nil {
+		return nil, err
+	}
+	return &pendingRefund{refund: rf, payment: pay, lineQty: lineQty, full: full}, nil
+}
+
+// refundAmount prices the units in lineQty and checks the amount against
+// what is still refundable on the payment. A refund of everything left on
+// the order, or of all of it, takes whatever is refundable, shipping and
+// rounding differences included. full reports that nothing refundable
+// remains afterwards.
+func refundAmount(o *domain.Order, lineQty map[int]int, refundable domain.Money, all bool) (lines []domain.RefundLine, amount domain.Money, full bool, err error) {
+	lines = refundLines(o, lineQty)
+	amount = domain.Money{Currency: refundable.Currency}
+	for _, l := range lines {
+		amount.Amount += l.Amount.Amount
+	}
+	if all || refundsEverything(o, lineQty) {
+		amount = refundable
+	}
+	if amount.Amount <= 0 {
+		return nil, domain.Money{}, false, fmt.Errorf("%w: nothing left to refund", ErrInvalidRefund)
+	}
+	cmp, err := amount.Cmp(refundable)
+	if err != nil {
+		return nil, domain.Money{}, false, err
+	}
+	if cmp > 0 {
+		return nil, domain.Money{}, false, fmt.Errorf("%w: %s requested, %s refundable", ErrRefundExceedsCaptured, amount, refundable)
+	}
+	return lines, amount, cmp == 0, nil
+}
+
+// complete records a refund the provider accepted, moves the order to
+// refunded or partially_refunded and restocks the refunded units if asked.
+func (s *refundService) complete(ctx context.Context, pr *pendingRefund, providerRef, actorID string, restock bool) error {
+	rf := pr.refund
+	if err := s.repo.Settle(ctx, rf.ID, domain.RefundSucceeded, providerRef, ""); err != nil {
+		return err
+	}
+	if pr.full {
+		if err := s.paymentRepo.UpdateStatus(ctx, pr.payment.ID, domain.PaymentCaptured, domain.PaymentRefunded, ""); err != nil {
+			return err
+		}
+	}
+	o, err := s.orderRepo.GetByID(ctx, rf.OrderID)
+	if err != nil {
+		return err
+	}
+	target := domain.OrderPartiallyRefunded
+	if pr.full {
+		target = domain.OrderRefunded
+	}
+	// an overlapping refund may already have moved the order on, and
+	// canceled orders keep their status
+	if canTransition(o.Status, target) {
+		reason := "refund " + rf.ID
+		if rf.Reason != "" {
+			reason += ": " + rf.Reason
+		}
+		change := domain.StatusChange{
+			From:    o.Status,
+			To:      target,
+			At:      time.Now().UTC(),
+			ActorID: actorID,
+			Reason:  reason,
+		}
+		if err := s.orderRepo.UpdateStatus(ctx, o.ID, change); err != nil {
+			return err
+		}
+	}
+	if restock {
+		for i, qty := range pr.lineQty {
+			err := s.productRepo.IncrementStock(ctx, o.Items[i].ProductID, qty)
+			// products deleted since the order was placed have nothing to restock
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// release undoes the reservation of a refund the provider rejected.
+func (s *refundService) release(ctx context.Context, pr *pendingRefund, cause error) error {
+	rf := pr.refund
+	undo := make(map[int]int, len(pr.lineQty))
+	for i, qty := range pr.lineQty {
+		undo[i] = -qty
+	}
+	if err := s.orderRepo.AddRefund(ctx, rf.OrderID, undo, rf.Amount.Neg()); err != nil {
+		return err
+	}
+	if err := s.paymentRepo.AddRefund(ctx, pr.payment.ID, rf.Amount.Neg()); err != nil {
+		return err
+	}
+	return s.repo.Settle(ctx, rf.ID, domain.RefundFailed, "", cause.Error())
+}
+
+// capturedPayment returns the payment that paid for the order.
+func (s *refundService) capturedPayment(ctx context.Context, orderID string) (*domain.Payment, error) {
+	payments, err := s.paymentRepo.ListByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range payments {
+		if p.Status == domain.PaymentCaptured {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %w", ErrOrderNotRefundable, errNoCapturedPayment)
+}
+
+// refundQuantities maps the requested items onto order line indexes. With no
+// items every unit not yet refunded is selected. A product ordered on several
+// lines is refunded from the first line with units left.
+func refundQuantities(o *domain.Order, items []RefundItem) (map[int]int, error) {
+	lineQty := make(map[int]int)
+	if len(items) == 0 {
+		for i, it := range o.Items {
+			if left := it.Quantity - it.RefundedQuantity; left > 0 {
+				lineQty[i] = left
+			}
+		}
+		return lineQty, nil
+	}
+	for _, req := range items {
+		need, ordered := req.Quantity, false
+		for i, it := range o.Items {
+			if it.ProductID != req.ProductID {
+				continue
+			}
+			ordered = true
+			take := min(need, it.Quantity-it.RefundedQuantity-lineQty[i])
+			if take > 0 {
+				lineQty[i] += take
+				need -= take
+			}
+		}
+		if !ordered {
+			return nil, fmt.Errorf("%w: product %s is not on the order", ErrInvalidRefund, req.ProductID)
+		}
+		if need > 0 {
+			return nil, fmt.Errorf("%w: only %d of product %s left to refund", ErrInvalidRefund, req.Quantity-need, req.ProductID)
+		}
+	}
+	return lineQty, nil
+}
+
+// refundLines prices the selected units. Each line carries its share of the
+// order's discount and, unless prices included it, the line's tax.
+func refundLines(o *domain.Order, lineQty map[int]int) []domain.RefundLine {
+	lines := []domain.RefundLine{}
+	for i, it := range o.Items {
+		qty := lineQty[i]
+		if qty == 0 {
+			continue
+		}
+		amount := it.Price.Mul(qty)
+		if o.Subtotal.Amount > 0 {
+			gross := amount.Amount
+			amount.Amount -= o.Discount.MulRatio(gross, o.Subtotal.Amount).Amount
+			switch {
+			case o.TaxInclusive:
+			case o.ShippingTax.Currency == "":
+				// orders placed before tax was recorded per line
+				amount.Amount += o.Tax.MulRatio(gross, o.Subtotal.Amount).Amount
+			default:
+				amount.Amount += it.Tax.MulRatio(int64(qty), int64(it.Quantity)).Amount
+			}
+		}
+		lines = append(lines, domain.RefundLine{ProductID: it.ProductID, SKU: it.SKU, Quantity: qty, Amount: amount})
+	}
+	return lines
+}
+
+// refundsEverything reports whether lineQty covers every unit of the order
+// not refunded yet.
+func refundsEverything(o *domain.Order, lineQty map[int]int) bool {
+	for i, it := range o.Items {
+		if it.RefundedQuantity+lineQty[i] < it.Quantity {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *refundService) ListByOrder(ctx context.Context, orderID string) ([]*domain.Refund, error) {
+	if _, err := s.orderRepo.GetByID(ctx, orderID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return s.repo.ListByOrder(ctx, orderID)
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+)
+
+func usd(amount int64) domain.Money { return domain.NewMoney(amount, "USD") }
+
+// refundOrder is a 30.00 order: two units of a at 10.00 and one of b at
+// 5.00, 8% tax on each line and 3.00 shipping.
+func refundOrder() *domain.Order {
+	return &domain.Order{
+		Items: []domain.OrderItem{
+			{ProductID: "a", Quantity: 2, Price: usd(1000), Tax: usd(160)},
+			{ProductID: "b", Quantity: 1, Price: usd(500), Tax: usd(40)},
+		},
+		Subtotal:    usd(2500),
+		Tax:         usd(200),
+		Shipping:    usd(300),
+		ShippingTax: usd(0),
+		Total:       usd(3000),
+	}
+}
+
+func TestRefundQuantities(t *testing.T) {
+	tests := []struct {
+		name     string
+		refunded []int
+		items    []RefundItem
+		want     map[int]int
+		wantErr  error
+	}{
+		{"everything by default", nil, nil, map[int]int{0: 2, 1: 1}, nil},
+		{"everything not yet refunded", []int{1, 1}, nil, map[int]int{0: 1}, nil},
+		{"some units", nil, []RefundItem{{"a", 1}}, map[int]int{0: 1}, nil},
+		{"same product twice", nil, []RefundItem{{"a", 1}, {"a", 1}}, map[int]int{0: 2}, nil},
+		{"more than ordered", nil, []RefundItem{{"b", 2}}, nil, ErrInvalidRefund},
+		{"already refunded", []int{2, 0}, []RefundItem{{"a", 1}}, nil, ErrInvalidRefund},
+		{"not on the order", nil, []RefundItem{{"c", 1}}, nil, ErrInvalidRefund},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := refundOrder()
+			for i, n := range tt.refunded {
+				o.Items[i].RefundedQuantity = n
+			}
+			got, err := refundQuantities(o, tt.items)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("refundQuantities() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("refundQuantities() = %v, want %v", got, tt.want)
+			}
+			for i, n := range tt.want {
+				if got[i] != n {
+					t.Fatalf("refundQuantities() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRefundQuantitiesAcrossLines(t *testing.T) {
+	o := refundOrder()
+	o.Items = append(o.Items, domain.OrderItem{ProductID: "a", Quantity: 3, Price: usd(1000)})
+	o.Items[0].RefundedQuantity = 1
+	got, err := refundQuantities(o, []RefundItem{{"a", 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first line has one unit left, the rest come from the second
+	if len(got) != 2 || got[0] != 1 || got[2] != 2 {
+		t.Errorf("refundQuantities() = %v, want map[0:1 2:2]", got)
+	}
+}
+
+func TestRefundAmount(t *testing.T) {
+	tests := []struct {
+		name       string
+		order      func(o *domain.Order)
+		lineQty    map[int]int
+		all        bool
+		refundable int64
+		want       int64
+		wantLines  []int64
+		wantFull   bool
+		wantErr    error
+	}{
+		// 10.00 plus half of the line's 1.60 tax
+		{"partial", nil, map[int]int{0: 1}, false, 3000, 1080, []int64{1080}, false, nil},
+		{"partial of two lines", nil, map[int]int{0: 1, 1: 1}, false, 3000, 1620, []int64{1080, 540}, false, nil},
+		// the last units take the shipping with them
+		{"every unit", nil, map[int]int{0: 2, 1: 1}, false, 3000, 3000, []int64{2160, 540}, true, nil},
+		{"all", nil, map[int]int{0: 2, 1: 1}, true, 3000, 3000, []int64{2160, 540}, true, nil},
+		{"rest after an earlier refund", func(o *domain.Order) { o.Items[0].RefundedQuantity = 1 },
+			map[int]int{0: 1, 1: 1}, false, 1920, 1920, []int64{1080, 540}, true, nil},
+		{"exactly what is refundable", nil, map[int]int{0: 1}, false, 1080, 1080, []int64{1080}, true, nil},
+		{"more than refundable", nil, map[int]int{0: 1}, false, 1000, 0, nil, false, ErrRefundExceedsCaptured},
+		{"nothing refundable", nil, map[int]int{}, true, 0, 0, nil, false, ErrInvalidRefund},
+		// 10.00 less its 1.00 share of the 2.50 discount, plus tax
+		{"discount shared by value", func(o *domain.Order) { o.Discount = usd(250) },
+			map[int]int{0: 1}, false, 2750, 980, []int64{980}, false, nil},
+		{"tax-inclusive prices", func(o *domain.Order) { o.TaxInclusive = true },
+			map[int]int{0: 1}, false, 2800, 1000, []int64{1000}, false, nil},
+		// orders from before per-line tax share the order's tax by value
+		{"legacy order tax", func(o *domain.Order) { o.ShippingTax = domain.Money{}; o.Items[0].Tax = domain.Money{} },
+			map[int]int{0: 1}, false, 3000, 1080, []int64{1080}, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := refundOrder()
+			if tt.order != nil {
+				tt.order(o)
+			}
+			lines, amount, full, err := refundAmount(o, tt.lineQty, usd(tt.refundable), tt.all)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("refundAmount() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if amount != usd(tt.want) || full != tt.wantFull {
+				t.Errorf("refundAmount() = %v, full %v; want %v, full %v", amount, full, usd(tt.want), tt.wantFull)
+			}
+			if len(lines) != len(tt.wantLines) {
+				t.Fatalf("lines = %+v, want amounts %v", lines, tt.wantLines)
+			}
+			for i, want := range tt.wantLines {
+				if lines[i].Amount != usd(want) {
+					t.Errorf("line %d = %v, want %v", i, lines[i].Amount, usd(want))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/repository"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidReservationStatus = errors.New("invalid reservation status")
+
+const (
+	sweepBatchSize = 100
+	// expiredReason is recorded on reservations and orders released by the
+	// sweep.
+	expiredReason = "payment window expired"
+)
+
+type ReservationService interface {
+	List(ctx context.Context, status domain.ReservationStatus, productID string, limit, page int) ([]*domain.Reservation, int64, error)
+	// StockLevels reports available and reserved units of every product
+	// held by an active reservation, or of productID alone.
+	StockLevels(ctx context.Context, productID string) ([]domain.StockLevel, error)
+	// ReleaseExpired cancels the unpaid orders whose reservations expired
+	// by now, putting their stock back on sale, and reports how many it
+	// released.
+	ReleaseExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type reservationService struct {
+	repo        repository.ReservationRepository
+	productRepo repository.ProductRepository
+	orders      OrderService
+	tx          repository.Transactor
+	logger      *zap.Logger
+}
+
+func NewReservationService(r repository.ReservationRepository, pr repository.ProductRepository, orders OrderService, tx repository.Transactor, logger *zap.Logger) ReservationService {
+	return &reservationService{repo: r, productRepo: pr, orders: orders, tx: tx, logger: logger}
+}
+
+func (s *reservationService) List(ctx context.Context, status domain.ReservationStatus, productID string, limit, page int) ([]*domain.Reservation, int64, error) {
+	switch status {
+	case "", domain.ReservationActive, domain.ReservationCommitted, domain.ReservationReleased:
+	default:
+		return nil, 0, fmt.Errorf("%w: %q", ErrInvalidReservationStatus, status)
+	}
+	if limit > maxOrderPageSize {
+		limit = maxOrderPageSize
+	}
+	return s.repo.List(ctx, status, productID, limit, page)
+}
+
+func (s *reservationService) StockLevels(ctx context.Context, productID string) ([]domain.StockLevel, error) {
+	levels, err := s.repo.Reserved(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if productID != "" && len(levels) == 0 {
+		// nothing reserved, but the product's stock is still of interest
+		levels = []domain.StockLevel{{ProductID: productID}}
+	}
+	out := levels[:0]
+	for _, l := range levels {
+		p, err := s.productRepo.GetByID(ctx, l.ProductID)
+		if errors.Is(err, repository.ErrNotFound) {
+			if productID != "" {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownProduct, productID)
+			}
+			// deleted while orders still held it; nothing is for sale
+			l.OnHand = l.Reserved
+			out = append(out, l)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		l.SKU = p.SKU
+		l.Available = p.Stock
+		l.OnHand = l.Available + l.Reserved
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+func (s *reservationService) ReleaseExpired(ctx context.Context, now time.Time) (int, error) {
+	released := 0
+	// reservations that fail stay active; paging past them keeps them from
+	// holding up newer ones, and the next sweep retries them once more
+	var after *domain.Reservation
+	for {
+		batch, err := s.repo.ListExpired(ctx, now, after, sweepBatchSize)
+		if err != nil {
+			return released, err
+		}
+		for _, rv := range batch {
+			ok, err := s.expire(ctx, rv)
+			if err != nil {
+				s.logger.Error("could not release expired reservation",
+					zap.String("order_id", rv.OrderID), zap.Error(err))
+				continue
+			}
+			if ok {
+				released++
+			}
+		}
+		if len(batch) < sweepBatchSize {
+			return released, nil
+		}
+		after = batch[len(batch)-1]
+	}
+}
+
+// expire releases rv and cancels its order in one transaction. It reports
+// false when the order was paid first. Releasing before canceling means a
+// payment that commits the reservation concurrently makes one of the two
+// transactions fail, so a paid order is never canceled here.
+func (s *reservationService) expire(ctx context.Context, rv *domain.Reservation) (bool, error) {
+	released := true
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		released = true
+		if err := s.repo.Close(ctx, rv.OrderID, domain.ReservationReleased, expiredReason); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				released = false
+				return nil
+			}
+			return err
+		}
+		_, err := s.orders.Cancel(ctx, rv.OrderID, "", true, expiredReason)
+		if errors.Is(err, ErrOrderNotFound) || errors.Is(err, ErrInvalidTransition) {
+			// the order is gone or already closed; only the reservation
+			// record was left behind
+			s.logger.Warn("released reservation of an order that could not be canceled",
+				zap.String("order_id", rv.OrderID), zap.Error(err))
+			return nil
+		}
+		return err
+	})
+	return released, err
+}
+
+// SweepReservations releases expired reservations every interval until ctx
+// is canceled.
+func SweepReservations(ctx context.Context, svc ReservationService, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := svc.ReleaseExpired(ctx, time.Now().UTC())
+			if err != nil && ctx.Err() == nil {
+				logger.Error("reservation sweep failed", zap.Error(err))
+			}
+			if n > 0 {
+				logger.Info("released expired reservations", zap.Int("count", n))
+			}
+		}
+	}
+}
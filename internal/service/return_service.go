@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/repository"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrReturnNotFound          = errors.New("return not found")
+	ErrInvalidReturn           = errors.New("invalid return")
+	ErrOrderNotReturnable      = errors.New("order cannot be returned")
+	ErrInvalidReturnStatus     = errors.New("invalid return status")
+	ErrInvalidReturnTransition = errors.New("invalid return status transition")
+)
+
+const (
+	maxReturnPhotos       = 5
+	maxReturnReasonLength = 2000
+)
+
+// returnTransitions lists, for every return status, the statuses it may move
+// to next. Statuses with no entries are terminal.
+var returnTransitions = map[domain.ReturnStatus][]domain.ReturnStatus{
+	domain.ReturnRequested: {domain.ReturnApproved, domain.ReturnRejected, domain.ReturnCanceled},
+	domain.ReturnApproved:  {domain.ReturnReceived, domain.ReturnRejected, domain.ReturnCanceled},
+	// received goods are inspected and either accepted or rejected
+	domain.ReturnReceived:  {domain.ReturnAccepted, domain.ReturnRejected},
+	domain.ReturnAccepted:  {domain.ReturnRefunded, domain.ReturnExchanged},
+	domain.ReturnRefunded:  {},
+	domain.ReturnExchanged: {},
+	domain.ReturnRejected:  {},
+	domain.ReturnCanceled:  {},
+}
+
+// ReturnItem selects units of one ordered product to return.
+type ReturnItem struct {
+	ProductID string
+	Quantity  int
+}
+
+// ReturnInput is a customer's return request.
+type ReturnInput struct {
+	Items     []ReturnItem
+	Reason    string
+	PhotoURLs []string
+}
+
+type ReturnService interface {
+	Request(ctx context.Context, orderID, userID string, in ReturnInput) (*domain.Return, error)
+	// GetForUser returns the return if the caller owns it or is an admin.
+	GetForUser(ctx context.Context, id, userID string, isAdmin bool) (*domain.Return, error)
+	ListByUser(ctx context.Context, userID string) ([]*domain.Return, error)
+	List(ctx context.Context, status domain.ReturnStatus, limit, page int) ([]*domain.Return, int64, error)
+	// Cancel withdraws a customer's own return before the goods arrive.
+	Cancel(ctx context.Context, id, userID, note string) (*domain.Return, error)
+	// UpdateStatus moves a return along its lifecycle. Accepting it restocks
+	// the returned units, refunding it refunds them through the order's
+	// payment, and exchanging it takes replacement units out of stock.
+	UpdateStatus(ctx context.Context, id string, status domain.ReturnStatus, actorID, note string) (*domain.Return, error)
+}
+
+type returnService struct {
+	repo        repository.ReturnRepository
+	orderRepo   repository.OrderRepository
+	productRepo repository.ProductRepository
+	tx          repository.Transactor
+	refunds     RefundService
+	logger      *zap.Logger
+}
+
+func NewReturnService(r repository.ReturnRepository, or repository.OrderRepository, pr repository.ProductRepository, tx repository.Transactor, refunds RefundService, logger *zap.Logger) ReturnService {
+	return &returnService{
+		repo:        r,
+		orderRepo:   or,
+		productRepo: pr,
+		tx:          tx,
+		refunds:     refunds,
+		logger:      logger,
+	}
+}
+
+// Request opens a return for shipped units of the user's order. The units
+// are held on the order so they cannot be returned twice.
+func (s *returnService) Request(ctx context.Context, orderID, userID string, in ReturnInput) (*domain.Return, error) {
+	if err := validateReturnInput(&in); err != nil {
+		return nil, err
+	}
+	var out *domain.Return
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		o, err := s.orderRepo.GetByID(ctx, orderID)
+		if errors.Is(err, repository.ErrNotFound) || err == nil && o.UserID != userID {
+			return ErrOrderNotFound
+		}
+		if err != nil {
+			return err
+		}
+		switch o.Status {
+		case domain.OrderPartiallyShipped, domain.OrderShipped, domain.OrderDelivered, domain.OrderPartiallyRefunded:
+		default:
+			return fmt.Errorf("%w: %s orders cannot be returned", ErrOrderNotReturnable, o.Status)
+		}
+		refunded, err := s.refundedReturnUnits(ctx, o.ID)
+		if err != nil {
+			return err
+		}
+		lineQty, err := returnQuantities(o, in.Items, refunded)
+		if err != nil {
+			return err
+		}
+		if err := s.orderRepo.AddReturned(ctx, o, lineQty); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return fmt.Errorf("%w: order was modified concurrently", ErrOrderNotReturnable)
+			}
+			return err
+		}
+		now := time.Now().UTC()
+		rt := &domain.Return{
+			OrderID:   o.ID,
+			UserID:    userID,
+			Reason:    in.Reason,
+			PhotoURLs: in.PhotoURLs,
+			Status:    domain.ReturnRequested,
+			History:   []domain.ReturnStatusChange{{To: domain.ReturnRequested, At: now, ActorID: userID}},
+		}
+		for i, it := range o.Items {
+			if qty := lineQty[i]; qty > 0 {
+				rt.Lines = append(rt.Lines, domain.ReturnLine{ProductID: it.ProductID, SKU: it.SKU, Name: it.Name, Quantity: qty})
+			}
+		}
+		if err := s.repo.Create(ctx, rt); err != nil {
+			return err
+		}
+		out = rt
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *returnService) GetForUser(ctx context.Context, id, userID string, isAdmin bool) (*domain.Return, error) {
+	rt, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && rt.UserID != userID {
+		return nil, ErrReturnNotFound
+	}
+	return rt, nil
+}
+
+func (s *returnService) ListByUser(ctx context.Context, userID string) ([]*domain.Return, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+func (s *returnService) List(ctx context.Context, status domain.ReturnStatus, limit, page int) ([]*domain.Return, int64, error) {
+	if _, ok := returnTransitions[status]; status != "" && !ok {
+		return nil, 0, fmt.Errorf("%w: %q", ErrInvalidReturnStatus, status)
+	}
+	return s.repo.List(ctx, status, limit, page)
+}
+
+func (s *returnService) Cancel(ctx context.Context, id, userID, note string) (*domain.Return, error) {
+	rt, err := s.GetForUser(ctx, id, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	return s.transition(ctx, rt, domain.ReturnCanceled, userID, note)
+}
+
+func (s *returnService) UpdateStatus(ctx context.Context, id string, status domain.ReturnStatus, actorID, note string) (*domain.Return, error) {
+	if _, ok := returnTransitions[status]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidReturnStatus, status)
+	}
+	rt, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if status == domain.ReturnRefunded {
+		return s.refund(ctx, rt, actorID, note)
+	}
+	return s.transition(ctx, rt, status, actorID, note)
+}
+
+// transition applies a status change and its stock side effects in one
+// transaction.
+func (s *returnService) transition(ctx context.Context, rt *domain.Return, to domain.ReturnStatus, actorID, note string) (*domain.Return, error) {
+	if !canTransitionReturn(rt.Status, to) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidReturnTransition, rt.Status, to)
+	}
+	change := domain.ReturnStatusChange{From: rt.Status, To: to, At: time.Now().UTC(), ActorID: actorID, Note: note}
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		switch to {
+		case domain.ReturnRejected, domain.ReturnCanceled:
+			if err := s.releaseUnits(ctx, rt); err != nil {
+				return err
+			}
+		case domain.ReturnAccepted:
+			for _, l := range rt.Lines {
+				err := s.productRepo.IncrementStock(ctx, l.ProductID, l.Quantity)
+				// products deleted since the order was placed have nothing to restock
+				if err != nil && !errors.Is(err, repository.ErrNotFound) {
+					return err
+				}
+			}
+			rt.Restocked = true
+		case domain.ReturnExchanged:
+			if err := s.takeReplacements(ctx, rt); err != nil {
+				return err
+			}
+		}
+		if err := s.repo.UpdateStatus(ctx, rt, change); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return fmt.Errorf("%w: return was modified concurrently", ErrInvalidReturnTransition)
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	rt.Status = to
+	rt.UpdatedAt = change.At
+	rt.History = append(rt.History, change)
+	return rt, nil
+}
+
+// refund marks an accepted return refunded and refunds its units. The
+// status is claimed first so that the refund is issued at most once; if the
+// refund fails the return goes back to accepted.
+func (s *returnService) refund(ctx context.Context, rt *domain.Return, actorID, note string) (*domain.Return, error) {
+	from := rt.Status
+	if _, err := s.transition(ctx, rt, domain.ReturnRefunded, actorID, note); err != nil {
+		return nil, err
+	}
+	req := RefundRequest{Reason: "return " + rt.ID}
+	for _, l := range rt.Lines {
+		req.Items = append(req.Items, RefundItem{ProductID: l.ProductID, Quantity: l.Quantity})
+	}
+	// accepted returns were restocked already
+	rf, err := s.refunds.Refund(ctx, rt.OrderID, actorID, req)
+	if err != nil {
+		revert := domain.ReturnStatusChange{
+			From:    domain.ReturnRefunded,
+			To:      from,
+			At:      time.Now().UTC(),
+			ActorID: actorID,
+			Note:    "refund failed: " + err.Error(),
+		}
+		if rerr := s.repo.UpdateStatus(ctx, rt, revert); rerr != nil {
+			s.logger.Error("could not reopen return after failed refund", zap.String("return_id", rt.ID), zap.Error(rerr))
+		}
+		return nil, err
+	}
+	rt.RefundID = rf.ID
+	if err := s.repo.SetRefund(ctx, rt.ID, rf.ID); err != nil {
+		s.logger.Error("refund issued but not linked to return",
+			zap.String("return_id", rt.ID), zap.String("refund_id", rf.ID), zap.Error(err))
+	}
+	return rt, nil
+}
+
+// releaseUnits gives the return's units back to the order so they can be
+// returned again.
+func (s *returnService) releaseUnits(ctx context.Context, rt *domain.Return) error {
+	o, err := s.orderRepo.GetByID(ctx, rt.OrderID)
+	if err != nil {
+		return err
+	}
+	lineQty := make(map[int]int)
+	for _, l := range rt.Lines {
+		need := l.Quantity
+		for i, it := range o.Items {
+			if it.ProductID != l.ProductID || need == 0 {
+				continue
+			}
+			take := min(need, it.ReturnedQuantity+lineQty[i])
+			lineQty[i] -= take
+			need -= take
+		}
+	}
+	return s.orderRepo.AddReturned(ctx, o, lineQty)
+}
+
+// takeReplacements takes the units sent out in exchange for the returned
+// ones out of stock.
+func (s *returnService) takeReplacements(ctx context.Context, rt *domain.Return) error {
+	var shortages []StockShortage
+	for _, l := range rt.Lines {
+		err := s.productRepo.DecrementStock(ctx, l.ProductID, l.Quantity)
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%w: %s", ErrUnknownProduct, l.ProductID)
+		}
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			p, err := s.productRepo.GetByID(ctx, l.ProductID)
+			if err != nil {
+				return err
+			}
+			shortages = append(shortages, StockShortage{ProductID: l.ProductID, SKU: l.SKU, Requested: l.Quantity, Available: p.Stock})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(shortages) > 0 {
+		return &InsufficientStockError{Items: shortages}
+	}
+	return nil
+}
+
+func (s *returnService) get(ctx context.Context, id string) (*domain.Return, error) {
+	rt, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrReturnNotFound
+	}
+	return rt, err
+}
+
+func canTransitionReturn(from, to domain.ReturnStatus) bool {
+	for _, next := range returnTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func validateReturnInput(in *ReturnInput) error {
+	in.Reason = strings.TrimSpace(in.Reason)
+	switch {
+	case in.Reason == "":
+		return fmt.Errorf("%w: reason is required", ErrInvalidReturn)
+	case len(in.Reason) > maxReturnReasonLength:
+		return fmt.Errorf("%w: reason must be at most %d characters", ErrInvalidReturn, maxReturnReasonLength)
+	case len(in.Items) == 0:
+		return fmt.Errorf("%w: items are required", ErrInvalidReturn)
+	case len(in.PhotoURLs) > maxReturnPhotos:
+		return fmt.Errorf("%w: at most %d photos", ErrInvalidReturn, maxReturnPhotos)
+	}
+	for _, it := range in.Items {
+		if it.Quantity <= 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidQuantity, it.ProductID)
+		}
+	}
+	for _, raw := range in.PhotoURLs {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("%w: photo %q is not an http(s) URL", ErrInvalidReturn, raw)
+		}
+	}
+	return nil
+}
+
+// refundedReturnUnits counts, per product, the units of the order that were
+// refunded through a return.
+func (s *returnService) refundedReturnUnits(ctx context.Context, orderID string) (map[string]int, error) {
+	returns, err := s.repo.ListByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]int)
+	for _, rt := range returns {
+		if rt.RefundID == "" {
+			continue
+		}
+		for _, l := range rt.Lines {
+			out[l.ProductID] += l.Quantity
+		}
+	}
+	return out, nil
+}
+
+// returnable counts the units of each order line that can still be
+// returned: shipped units that are neither on a return nor refunded.
+// refundedReturns holds, per product, the units refunded through returns,
+// which are on both the returned and the refunded quantity. Other refunds
+// are taken from the units never shipped first, as shipping does, so only
+// the rest of them reduce what can be returned.
+func returnable(o *domain.Order, refundedReturns map[string]int) []int {
+	overlap := make(map[string]int, len(refundedReturns))
+	for id, n := range refundedReturns {
+		overlap[id] = n
+	}
+	left := make([]int, len(o.Items))
+	for i, it := range o.Items {
+		both := min(overlap[it.ProductID], it.ReturnedQuantity, it.RefundedQuantity)
+		overlap[it.ProductID] -= both
+		refundedShipped := max(it.RefundedQuantity-both-(it.Quantity-it.ShippedQuantity), 0)
+		left[i] = max(it.ShippedQuantity-it.ReturnedQuantity-refundedShipped, 0)
+	}
+	return left
+}
+
+// returnQuantities maps the requested items onto order lines, returning the
+// units to return per line index. Only the units returnable reports can be
+// returned.
+func returnQuantities(o *domain.Order, items []ReturnItem, refundedReturns map[string]int) (map[int]int, error) {
+	left := returnable(o, refundedReturns)
+	lineQty := make(map[int]int)
+	for _, req := range items {
+		need, ordered := req.Quantity, false
+		for i, it := range o.Items {
+			if it.ProductID != req.ProductID {
+				continue
+			}
+			ordered = true
+			take := min(need, left[i]-lineQty[i])
+			if take > 0 {
+				lineQty[i] += take
+				need -= take
+			}
+		}
+		if !ordered {
+			return nil, fmt.Errorf("%w: product %s is not on the order", ErrInvalidReturn, req.ProductID)
+		}
+		if need > 0 {
+			return nil, fmt.Errorf("%w: only %d of product %s can be returned", ErrOrderNotReturnable, req.Quantity-need, req.ProductID)
+		}
+	}
+	return lineQty, nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+)
+
+func TestReturnQuantities(t *testing.T) {
+	item := func(qty, shipped, returned, refunded int) domain.OrderItem {
+		return domain.OrderItem{ProductID: "a", Quantity: qty, ShippedQuantity: shipped, ReturnedQuantity: returned, RefundedQuantity: refunded}
+	}
+	tests := []struct {
+		name     string
+		item     domain.OrderItem
+		refunded map[string]int
+		want     int
+	}{
+		{"every shipped unit", item(2, 2, 0, 0), nil, 2},
+		{"unshipped units stay", item(3, 1, 0, 0), nil, 1},
+		{"units already on a return", item(2, 2, 1, 0), nil, 1},
+		{"refunded shipped unit", item(2, 2, 0, 1), nil, 1},
+		// the refund is taken from the unit that never shipped
+		{"refunded unshipped unit", item(3, 2, 0, 1), nil, 2},
+		{"refunded through a return", item(2, 2, 1, 1), map[string]int{"a": 1}, 1},
+		{"refunded and on a return", item(2, 2, 1, 1), nil, 0},
+		{"refunded through a return and directly", item(2, 2, 1, 2), map[string]int{"a": 1}, 0},
+		{"everything refunded", item(2, 2, 0, 2), nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &domain.Order{Items: []domain.OrderItem{tt.item}}
+			if tt.want > 0 {
+				got, err := returnQuantities(o, []ReturnItem{{ProductID: "a", Quantity: tt.want}}, tt.refunded)
+				if err != nil {
+					t.Fatalf("returning %d: %v", tt.want, err)
+				}
+				if got[0] != tt.want {
+					t.Errorf("returnQuantities() = %v, want %d units of line 0", got, tt.want)
+				}
+			}
+			_, err := returnQuantities(o, []ReturnItem{{ProductID: "a", Quantity: tt.want + 1}}, tt.refunded)
+			if !errors.Is(err, ErrOrderNotReturnable) {
+				t.Errorf("returning %d: error = %v, want ErrOrderNotReturnable", tt.want+1, err)
+			}
+		})
+	}
+}
+
+func TestReturnQuantitiesAcrossLines(t *testing.T) {
+	o := &domain.Order{Items: []domain.OrderItem{
+		{ProductID: "a", Quantity: 1, ShippedQuantity: 1, ReturnedQuantity: 1, RefundedQuantity: 1},
+		{ProductID: "b", Quantity: 1, ShippedQuantity: 1},
+		{ProductID: "a", Quantity: 2, ShippedQuantity: 2},
+	}}
+	// the return refund covers the first line of a, leaving the second whole
+	got, err := returnQuantities(o, []ReturnItem{{ProductID: "a", Quantity: 2}, {ProductID: "b", Quantity: 1}}, map[string]int{"a": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1] != 1 || got[2] != 2 {
+		t.Errorf("returnQuantities() = %v, want map[1:1 2:2]", got)
+	}
+	if _, err := returnQuantities(o, []ReturnItem{{ProductID: "c", Quantity: 1}}, nil); !errors.Is(err, ErrInvalidReturn) {
+		t.Errorf("unknown product: error = %v, want ErrInvalidReturn", err)
+	}
+}
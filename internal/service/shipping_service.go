@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/repository"
+)
+
+var (
+	ErrShippingMethodNotFound  = errors.New("shipping method not found")
+	ErrInvalidShippingMethod   = errors.New("invalid shipping method")
+	ErrDuplicateShippingMethod = errors.New("shipping method code already exists")
+	ErrShippingUnavailable     = errors.New("shipping method not available")
+)
+
+type ShippingService interface {
+	Create(ctx context.Context, m *domain.ShippingMethod) error
+	GetByID(ctx context.Context, id string) (*domain.ShippingMethod, error)
+	Update(ctx context.Context, m *domain.ShippingMethod) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]*domain.ShippingMethod, error)
+
+	// Available lists the active methods that ship to country. An empty
+	// country lists every active method.
+	Available(ctx context.Context, country string) ([]*domain.ShippingMethod, error)
+	// Quote prices shipping items to dest with the method code. goods is
+	// the value of the goods after discounts, used by free_over methods.
+	Quote(ctx context.Context, code string, dest *domain.Address, items []domain.OrderItem, goods domain.Money) (*domain.ShippingMethod, domain.Money, error)
+}
+
+type shippingService struct {
+	repo repository.ShippingMethodRepository
+}
+
+func NewShippingService(r repository.ShippingMethodRepository) ShippingService {
+	return &shippingService{repo: r}
+}
+
+func (s *shippingService) Create(ctx context.Context, m *domain.ShippingMethod) error {
+	if err := validateShippingMethod(m); err != nil {
+		return err
+	}
+	err := s.repo.Create(ctx, m)
+	if errors.Is(err, repository.ErrDuplicate) {
+		return fmt.Errorf("%w: %s", ErrDuplicateShippingMethod, m.Code)
+	}
+	return err
+}
+
+func (s *shippingService) GetByID(ctx context.Context, id string) (*domain.ShippingMethod, error) {
+	m, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrShippingMethodNotFound
+	}
+	return m, err
+}
+
+func (s *shippingService) Update(ctx context.Context, m *domain.ShippingMethod) error {
+	if err := validateShippingMethod(m); err != nil {
+		return err
+	}
+	err := s.repo.Update(ctx, m)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return ErrShippingMethodNotFound
+	case errors.Is(err, repository.ErrDuplicate):
+		return fmt.Errorf("%w: %s", ErrDuplicateShippingMethod, m.Code)
+	}
+	return err
+}
+
+func (s *shippingService) Delete(ctx context.Context, id string) error {
+	err := s.repo.Delete(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrShippingMethodNotFound
+	}
+	return err
+}
+
+func (s *shippingService) List(ctx context.Context) ([]*domain.ShippingMethod, error) {
+	return s.repo.List(ctx, false)
+}
+
+func (s *shippingService) Available(ctx context.Context, country string) ([]*domain.ShippingMethod, error) {
+	methods, err := s.repo.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if country == "" {
+		return methods, nil
+	}
+	out := make([]*domain.ShippingMethod, 0, len(methods))
+	for _, m := range methods {
+		if shipsTo(m, country) {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+func (s *shippingService) Quote(ctx context.Context, code string, dest *domain.Address, items []domain.OrderItem, goods domain.Money) (*domain.ShippingMethod, domain.Money, error) {
+	none := domain.Money{Currency: goods.Currency}
+	m, err := s.repo.GetByCode(ctx, strings.ToLower(strings.TrimSpace(code)))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, none, fmt.Errorf("%w: %s", ErrShippingMethodNotFound, code)
+	}
+	if err != nil {
+		return nil, none, err
+	}
+	if !m.Active {
+		return nil, none, fmt.Errorf("%w: %s is not active", ErrShippingUnavailable, m.Code)
+	}
+	if dest == nil && len(m.Countries) > 0 {
+		return nil, none, fmt.Errorf("%w: %s needs a shipping address", ErrShippingUnavailable, m.Code)
+	}
+	if dest != nil && !shipsTo(m, dest.Country) {
+		return nil, none, fmt.Errorf("%w: %s does not ship to %s", ErrShippingUnavailable, m.Code, dest.Country)
+	}
+
+	var cost domain.Money
+	switch m.Type {
+	case domain.ShippingFlat:
+		cost = m.Cost
+	case domain.ShippingFreeOver:
+		cost = m.Cost
+		if c, err := goods.Cmp(m.FreeOver); err == nil && c >= 0 {
+			cost = none
+		}
+	case domain.ShippingWeight:
+		grams := 0
+		for _, it := range items {
+			grams += it.WeightGrams * it.Quantity
+		}
+		found := false
+		for _, t := range m.WeightTiers {
+			if t.MaxGrams == 0 || grams <= t.MaxGrams {
+				cost, found = t.Cost, true
+				break
+			}
+		}
+		if !found {
+			return nil, none, fmt.Errorf("%w: %s does not take orders of %dg", ErrShippingUnavailable, m.Code, grams)
+		}
+	}
+	if cost.Amount == 0 {
+		return m, none, nil
+	}
+	if cost.Currency != goods.Currency {
+		return nil, none, fmt.Errorf("%w: %s is priced in %s", ErrShippingUnavailable, m.Code, cost.Currency)
+	}
+	return m, cost, nil
+}
+
+func shipsTo(m *domain.ShippingMethod, country string) bool {
+	return len(m.Countries) == 0 || slices.Contains(m.Countries, strings.ToUpper(country))
+}
+
+// validateShippingMethod normalizes the code, countries and money fields and
+// checks the rate settings match the method type.
+func validateShippingMethod(m *domain.ShippingMethod) error {
+	m.Code = strings.ToLower(strings.TrimSpace(m.Code))
+	m.Name = strings.TrimSpace(m.Name)
+	if m.Code == "" {
+		return fmt.Errorf("%w: code is required", ErrInvalidShippingMethod)
+	}
+	if m.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidShippingMethod)
+	}
+	for i, c := range m.Countries {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if len(c) != 2 {
+			return fmt.Errorf("%w: countries must be two-letter ISO codes", ErrInvalidShippingMethod)
+		}
+		m.Countries[i] = c
+	}
+	if err := normalizePrice(&m.Cost); err != nil {
+		return fmt.Errorf("%w: cost: %v", ErrInvalidShippingMethod, err)
+	}
+	switch m.Type {
+	case domain.ShippingFlat:
+		m.FreeOver, m.WeightTiers = domain.Money{}, nil
+	case domain.ShippingFreeOver:
+		if err := normalizePrice(&m.FreeOver); err != nil || m.FreeOver.Amount <= 0 {
+			return fmt.Errorf("%w: free_over must be a positive amount", ErrInvalidShippingMethod)
+		}
+		if m.Cost.Amount > 0 && m.Cost.Currency != m.FreeOver.Currency {
+			return fmt.Errorf("%w: cost and free_over must share a currency", ErrInvalidShippingMethod)
+		}
+		m.WeightTiers = nil
+	case domain.ShippingWeight:
+		if len(m.WeightTiers) == 0 {
+			return fmt.Errorf("%w: weight_tiers are required", ErrInvalidShippingMethod)
+		}
+		prev := 0
+		for i := range m.WeightTiers {
+			t := &m.WeightTiers[i]
+			if err := normalizePrice(&t.Cost); err != nil {
+				return fmt.Errorf("%w: weight_tiers[%d]: %v", ErrInvalidShippingMethod, i, err)
+			}
+			last := i == len(m.WeightTiers)-1
+			if t.MaxGrams < 0 || t.MaxGrams == 0 && !last || t.MaxGrams != 0 && t.MaxGrams <= prev {
+				return fmt.Errorf("%w: weight_tiers must have ascending max_grams; only the last may be 0", ErrInvalidShippingMethod)
+			}
+			prev = t.MaxGrams
+		}
+		m.Cost, m.FreeOver = domain.Money{}, domain.Money{}
+	default:
+		return fmt.Errorf("%w: type must be %q, %q or %q", ErrInvalidShippingMethod, domain.ShippingFlat, domain.ShippingWeight, domain.ShippingFreeOver)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/repository"
+)
+
+// shippingMethods serves methods by code; the other repository methods are
+// not used by Quote.
+type shippingMethods struct {
+	repository.ShippingMethodRepository
+	byCode map[string]*domain.ShippingMethod
+}
+
+func (r shippingMethods) GetByCode(ctx context.Context, code string) (*domain.ShippingMethod, error) {
+	m, ok := r.byCode[code]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return m, nil
+}
+
+func TestQuote(t *testing.T) {
+	svc := NewShippingService(shippingMethods{byCode: map[string]*domain.ShippingMethod{
+		"flat":     {Code: "flat", Type: domain.ShippingFlat, Cost: usd(500), Active: true},
+		"free":     {Code: "free", Type: domain.ShippingFlat, Active: true},
+		"over":     {Code: "over", Type: domain.ShippingFreeOver, Cost: usd(700), FreeOver: usd(5000), Active: true},
+		"domestic": {Code: "domestic", Type: domain.ShippingFlat, Cost: usd(300), Countries: []string{"US", "CA"}, Active: true},
+		"retired":  {Code: "retired", Type: domain.ShippingFlat, Cost: usd(100)},
+		"euro":     {Code: "euro", Type: domain.ShippingFlat, Cost: domain.NewMoney(400, "EUR"), Active: true},
+		"weight": {Code: "weight", Type: domain.ShippingWeight, Active: true, WeightTiers: []domain.WeightTier{
+			{MaxGrams: 1000, Cost: usd(400)},
+			{MaxGrams: 5000, Cost: usd(900)},
+		}},
+	}})
+	us := &domain.Address{Country: "US"}
+	items := func(grams, qty int) []domain.OrderItem {
+		return []domain.OrderItem{{ProductID: "a", Quantity: qty, WeightGrams: grams}}
+	}
+	tests := []struct {
+		name    string
+		code    string
+		dest    *domain.Address
+		items   []domain.OrderItem
+		goods   int64
+		want    int64
+		wantErr error
+	}{
+		{"flat", "flat", us, nil, 1000, 500, nil},
+		{"code is normalized", "  FLAT ", us, nil, 1000, 500, nil},
+		{"free method", "free", us, nil, 1000, 0, nil},
+		{"below the free threshold", "over", us, nil, 4999, 700, nil},
+		{"at the free threshold", "over", us, nil, 5000, 0, nil},
+		{"above the free threshold", "over", us, nil, 9000, 0, nil},
+		{"ships to the country", "domestic", &domain.Address{Country: "CA"}, nil, 1000, 300, nil},
+		{"does not ship to the country", "domestic", &domain.Address{Country: "FR"}, nil, 1000, 0, ErrShippingUnavailable},
+		{"country limit needs an address", "domestic", nil, nil, 1000, 0, ErrShippingUnavailable},
+		{"no limit needs no address", "flat", nil, nil, 1000, 500, nil},
+		{"inactive", "retired", us, nil, 1000, 0, ErrShippingUnavailable},
+		{"unknown", "teleport", us, nil, 1000, 0, ErrShippingMethodNotFound},
+		{"priced in another currency", "euro", us, nil, 1000, 0, ErrShippingUnavailable},
+		{"first weight tier", "weight", us, items(250, 4), 1000, 400, nil},
+		{"second weight tier", "weight", us, items(250, 5), 1000, 900, nil},
+		{"too heavy", "weight", us, items(2000, 3), 1000, 0, ErrShippingUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, cost, err := svc.Quote(context.Background(), tt.code, tt.dest, tt.items, usd(tt.goods))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Quote() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m == nil || cost != usd(tt.want) {
+				t.Errorf("Quote() = %v, %v; want %v", m, cost, usd(tt.want))
+			}
+		})
+	}
+}
+
+func TestQuoteFreeThresholdInAnotherCurrency(t *testing.T) {
+	svc := NewShippingService(shippingMethods{byCode: map[string]*domain.ShippingMethod{
+		"over": {Code: "over", Type: domain.ShippingFreeOver, Cost: usd(700), FreeOver: usd(5000), Active: true},
+	}})
+	// a EUR order never reaches a USD threshold, and cannot pay USD shipping
+	_, _, err := svc.Quote(context.Background(), "over", nil, nil, domain.NewMoney(9000, "EUR"))
+	if !errors.Is(err, ErrShippingUnavailable) {
+		t.Errorf("Quote() error = %v, want ErrShippingUnavailable", err)
+	}
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+)
+
+// TaxLine is an order line as seen by a TaxCalculator. Amount is what the
+// customer pays for the whole line after discounts.
+type TaxLine struct {
+	ProductID string
+	TaxClass  string
+	Amount    domain.Money
+}
+
+type TaxRequest struct {
+	Location domain.TaxLocation
+	Lines    []TaxLine
+	Shipping domain.Money
+}
+
+// LineTax is the tax on one TaxLine.
+type LineTax struct {
+	Tax     domain.Money
+	RateBPS int64
+}
+
+// TaxResult holds the tax for each requested line, in request order, and
+// for shipping. Inclusive reports that the amounts already contained the
+// tax, so it must not be added to the order total.
+type TaxResult struct {
+	Lines     []LineTax
+	Shipping  domain.Money
+	Inclusive bool
+}
+
+// TaxCalculator works out the tax on an order. The built-in implementation
+// is a rate table; an external tax service can be plugged in instead.
+type TaxCalculator interface {
+	Calculate(ctx context.Context, req TaxRequest) (*TaxResult, error)
+}
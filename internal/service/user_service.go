@@ -2,17 +2,38 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/rseigha/goecomapi/internal/domain"
 	"github.com/rseigha/goecomapi/internal/repository"
+	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.uber.org/zap"
 )
 
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrAddressNotFound = errors.New("address not found")
+	ErrInvalidAddress  = errors.New("invalid address")
+)
+
+const maxAddresses = 20
+
 type UserService interface {
 	GetByID(ctx context.Context, id string) (*domain.User, error)
 	Update(ctx context.Context, u *domain.User) error
 	Delete(ctx context.Context, id string) error
+
+	ListAddresses(ctx context.Context, userID string) ([]domain.Address, error)
+	AddAddress(ctx context.Context, userID string, a domain.Address) (*domain.Address, error)
+	UpdateAddress(ctx context.Context, userID string, a domain.Address) (*domain.Address, error)
+	DeleteAddress(ctx context.Context, userID, addressID string) error
+	// ResolveAddress returns the address book entry with the given ID, or
+	// the default entry when addressID is empty. It returns nil without an
+	// error when addressID is empty and the user has no default.
+	ResolveAddress(ctx context.Context, userID, addressID string) (*domain.Address, error)
 }
 
 type userService struct {
@@ -40,9 +61,160 @@ func (s *userService) GetByID(ctx context.Context, id string) (*domain.User, err
 
 func (s *userService) Update(ctx context.Context, u *domain.User) error {
 	u.UpdatedAt = time.Now().UTC()
+	// the address book is changed through its own methods
+	u.Addresses = nil
 	return s.userRep.Update(ctx, u)
 }
 
 func (s *userService) Delete(ctx context.Context, id string) error {
 	return s.userRep.Delete(ctx, id)
 }
+
+func (s *userService) addresses(ctx context.Context, userID string) ([]domain.Address, error) {
+	u, err := s.userRep.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if u.Addresses == nil {
+		return []domain.Address{}, nil
+	}
+	return u.Addresses, nil
+}
+
+func (s *userService) ListAddresses(ctx context.Context, userID string) ([]domain.Address, error) {
+	return s.addresses(ctx, userID)
+}
+
+// AddAddress adds an entry to the address book. The first address becomes
+// the default.
+func (s *userService) AddAddress(ctx context.Context, userID string, a domain.Address) (*domain.Address, error) {
+	if err := NormalizeAddress(&a); err != nil {
+		return nil, err
+	}
+	addrs, err := s.addresses(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) >= maxAddresses {
+		return nil, fmt.Errorf("%w: at most %d addresses can be saved", ErrInvalidAddress, maxAddresses)
+	}
+	a.ID = bson.NewObjectID().Hex()
+	if len(addrs) == 0 {
+		a.IsDefault = true
+	}
+	addrs = append(addrs, a)
+	if err := s.saveAddresses(ctx, userID, addrs, a.ID); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (s *userService) UpdateAddress(ctx context.Context, userID string, a domain.Address) (*domain.Address, error) {
+	if err := NormalizeAddress(&a); err != nil {
+		return nil, err
+	}
+	addrs, err := s.addresses(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	i := addressIndex(addrs, a.ID)
+	if i < 0 {
+		return nil, ErrAddressNotFound
+	}
+	addrs[i] = a
+	if err := s.saveAddresses(ctx, userID, addrs, a.ID); err != nil {
+		return nil, err
+	}
+	return &addrs[i], nil
+}
+
+// DeleteAddress removes an entry. If it was the default, the first remaining
+// address takes over.
+func (s *userService) DeleteAddress(ctx context.Context, userID, addressID string) error {
+	addrs, err := s.addresses(ctx, userID)
+	if err != nil {
+		return err
+	}
+	i := addressIndex(addrs, addressID)
+	if i < 0 {
+		return ErrAddressNotFound
+	}
+	wasDefault := addrs[i].IsDefault
+	addrs = append(addrs[:i], addrs[i+1:]...)
+	if wasDefault && len(addrs) > 0 {
+		addrs[0].IsDefault = true
+	}
+	return s.saveAddresses(ctx, userID, addrs, "")
+}
+
+func (s *userService) ResolveAddress(ctx context.Context, userID, addressID string) (*domain.Address, error) {
+	addrs, err := s.addresses(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range addrs {
+		if addressID == "" && addrs[i].IsDefault || addressID != "" && addrs[i].ID == addressID {
+			a := addrs[i]
+			return &a, nil
+		}
+	}
+	if addressID == "" {
+		return nil, nil
+	}
+	return nil, ErrAddressNotFound
+}
+
+// saveAddresses stores the address book, keeping exactly one default:
+// defaultID if it is marked default, otherwise whichever entry already was.
+func (s *userService) saveAddresses(ctx context.Context, userID string, addrs []domain.Address, defaultID string) error {
+	keep := ""
+	for _, a := range addrs {
+		if a.IsDefault && (keep == "" || a.ID == defaultID) {
+			keep = a.ID
+		}
+	}
+	if keep == "" && len(addrs) > 0 {
+		keep = addrs[0].ID
+	}
+	for i := range addrs {
+		addrs[i].IsDefault = addrs[i].ID == keep
+	}
+	err := s.userRep.SaveAddresses(ctx, userID, addrs)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+func addressIndex(addrs []domain.Address, id string) int {
+	for i, a := range addrs {
+		if a.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// NormalizeAddress trims the address and checks the fields needed to ship to
+// it.
+func NormalizeAddress(a *domain.Address) error {
+	for _, f := range []*string{&a.Label, &a.Name, &a.Line1, &a.Line2, &a.City, &a.State, &a.PostalCode, &a.Country, &a.Phone} {
+		*f = strings.TrimSpace(*f)
+	}
+	a.Country = strings.ToUpper(a.Country)
+	a.State = strings.ToUpper(a.State)
+	switch {
+	case a.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidAddress)
+	case a.Line1 == "":
+		return fmt.Errorf("%w: line1 is required", ErrInvalidAddress)
+	case a.City == "":
+		return fmt.Errorf("%w: city is required", ErrInvalidAddress)
+	case len(a.Country) != 2:
+		return fmt.Errorf("%w: country must be a two-letter ISO code", ErrInvalidAddress)
+	}
+	return nil
+}
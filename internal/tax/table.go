@@ -0,0 +1,124 @@
+// Package tax provides a table-driven service.TaxCalculator.
+package tax
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/service"
+)
+
+// Rule is one row of a tax table. Empty fields match anything, so a rule
+// with only RateBPS set is a catch-all.
+type Rule struct {
+	Country      string `json:"country"`
+	State        string `json:"state"`
+	PostalPrefix string `json:"postal_prefix"`
+	TaxClass     string `json:"tax_class"`
+	// RateBPS is the rate in basis points (825 = 8.25%).
+	RateBPS int64 `json:"rate_bps"`
+}
+
+// Table looks up rates by location and product tax class. When several
+// rules match, the most specific one wins: a matching tax class beats a
+// longer postal prefix, which beats a matching state, which beats a
+// matching country.
+type Table struct {
+	Rules []Rule `json:"rules"`
+	// Inclusive means catalog prices already include tax.
+	Inclusive bool `json:"inclusive"`
+	// ShippingTaxClass is the class used to tax shipping; empty leaves
+	// shipping untaxed.
+	ShippingTaxClass string `json:"shipping_tax_class"`
+}
+
+// Flat returns a table charging rateBPS everywhere on everything except
+// shipping.
+func Flat(rateBPS int64) *Table {
+	return &Table{Rules: []Rule{{RateBPS: rateBPS}}}
+}
+
+// Load reads a table from a JSON file.
+func Load(path string) (*Table, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var t Table
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return nil, fmt.Errorf("tax table %s: %w", path, err)
+	}
+	for i := range t.Rules {
+		r := &t.Rules[i]
+		if r.RateBPS < 0 {
+			return nil, fmt.Errorf("tax table %s: rule %d has a negative rate", path, i)
+		}
+		r.Country = strings.ToUpper(r.Country)
+		r.State = strings.ToUpper(r.State)
+		r.PostalPrefix = normalizePostal(r.PostalPrefix)
+	}
+	return &t, nil
+}
+
+func (t *Table) Calculate(_ context.Context, req service.TaxRequest) (*service.TaxResult, error) {
+	res := &service.TaxResult{Lines: make([]service.LineTax, len(req.Lines)), Inclusive: t.Inclusive}
+	for i, l := range req.Lines {
+		rate := t.Rate(req.Location, l.TaxClass)
+		res.Lines[i] = service.LineTax{Tax: t.tax(l.Amount, rate), RateBPS: rate}
+	}
+	res.Shipping = domain.Money{Currency: req.Shipping.Currency}
+	if t.ShippingTaxClass != "" {
+		res.Shipping = t.tax(req.Shipping, t.Rate(req.Location, t.ShippingTaxClass))
+	}
+	return res, nil
+}
+
+// tax returns the tax on amount: added on top for exclusive tables, taken
+// out of it for inclusive ones.
+func (t *Table) tax(amount domain.Money, rateBPS int64) domain.Money {
+	if t.Inclusive {
+		return amount.MulRatio(rateBPS, 10000+rateBPS)
+	}
+	return amount.MulRatio(rateBPS, 10000)
+}
+
+// Rate returns the rate of the most specific rule matching loc and class,
+// or zero if none does.
+func (t *Table) Rate(loc domain.TaxLocation, class string) int64 {
+	country := strings.ToUpper(loc.Country)
+	state := strings.ToUpper(loc.State)
+	postal := normalizePostal(loc.PostalCode)
+	best, bestScore := int64(0), -1
+	for _, r := range t.Rules {
+		if r.Country != "" && r.Country != country ||
+			r.State != "" && r.State != state ||
+			r.PostalPrefix != "" && !strings.HasPrefix(postal, r.PostalPrefix) ||
+			r.TaxClass != "" && r.TaxClass != class {
+			continue
+		}
+		score := 0
+		if r.Country != "" {
+			score++
+		}
+		if r.State != "" {
+			score += 2
+		}
+		// each prefix character outranks a state match
+		score += 3 * len(r.PostalPrefix)
+		if r.TaxClass != "" {
+			score += 1000
+		}
+		if score > bestScore {
+			best, bestScore = r.RateBPS, score
+		}
+	}
+	return best
+}
+
+func normalizePostal(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(code, " ", ""))
+}
@@ -0,0 +1,156 @@
+package tax
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rseigha/goecomapi/internal/domain"
+	"github.com/rseigha/goecomapi/internal/service"
+)
+
+func usd(amount int64) domain.Money { return domain.NewMoney(amount, "USD") }
+
+func TestRate(t *testing.T) {
+	table := &Table{Rules: []Rule{
+		{RateBPS: 500},
+		{Country: "US", RateBPS: 600},
+		{Country: "US", State: "CA", RateBPS: 725},
+		{Country: "US", PostalPrefix: "900", RateBPS: 950},
+		{Country: "US", PostalPrefix: "9001", RateBPS: 1025},
+		{Country: "US", TaxClass: "food", RateBPS: 100},
+		{Country: "GB", TaxClass: "books", RateBPS: 0},
+	}}
+	tests := []struct {
+		name  string
+		loc   domain.TaxLocation
+		class string
+		want  int64
+	}{
+		{"falls back to the catch-all", domain.TaxLocation{Country: "FR"}, "", 500},
+		{"country", domain.TaxLocation{Country: "US", State: "NY"}, "", 600},
+		{"state beats country", domain.TaxLocation{Country: "US", State: "CA"}, "", 725},
+		{"codes are case-insensitive", domain.TaxLocation{Country: "us", State: "ca"}, "", 725},
+		{"postal prefix beats state", domain.TaxLocation{Country: "US", State: "CA", PostalCode: "90099"}, "", 950},
+		{"longer prefix wins", domain.TaxLocation{Country: "US", State: "CA", PostalCode: "90012"}, "", 1025},
+		{"spaces in postal codes ignored", domain.TaxLocation{Country: "US", PostalCode: "900 12"}, "", 1025},
+		{"tax class beats location", domain.TaxLocation{Country: "US", State: "CA", PostalCode: "90012"}, "food", 100},
+		{"other classes use the location", domain.TaxLocation{Country: "US", State: "CA"}, "toys", 725},
+		{"zero-rated class", domain.TaxLocation{Country: "GB"}, "books", 0},
+		{"class rule limited to its country", domain.TaxLocation{Country: "FR"}, "books", 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := table.Rate(tt.loc, tt.class); got != tt.want {
+				t.Errorf("Rate(%+v, %q) = %d, want %d", tt.loc, tt.class, got, tt.want)
+			}
+		})
+	}
+	if got := (&Table{}).Rate(domain.TaxLocation{Country: "US"}, ""); got != 0 {
+		t.Errorf("empty table Rate() = %d, want 0", got)
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	rules := []Rule{{RateBPS: 825}, {TaxClass: "reduced", RateBPS: 500}, {TaxClass: "shipping", RateBPS: 2000}}
+	req := service.TaxRequest{
+		Location: domain.TaxLocation{Country: "US"},
+		Lines: []service.TaxLine{
+			{ProductID: "a", Amount: usd(1999)},
+			{ProductID: "b", TaxClass: "reduced", Amount: usd(1000)},
+			{ProductID: "c", Amount: usd(50)},
+		},
+		Shipping: usd(1200),
+	}
+	tests := []struct {
+		name     string
+		table    *Table
+		lines    []service.LineTax
+		shipping int64
+	}{
+		{
+			"exclusive",
+			&Table{Rules: rules},
+			// 164.9175 rounds up, 4.125 rounds down
+			[]service.LineTax{{Tax: usd(165), RateBPS: 825}, {Tax: usd(50), RateBPS: 500}, {Tax: usd(4), RateBPS: 825}},
+			0,
+		},
+		{
+			"exclusive with taxed shipping",
+			&Table{Rules: rules, ShippingTaxClass: "shipping"},
+			[]service.LineTax{{Tax: usd(165), RateBPS: 825}, {Tax: usd(50), RateBPS: 500}, {Tax: usd(4), RateBPS: 825}},
+			240,
+		},
+		{
+			"inclusive",
+			&Table{Rules: rules, Inclusive: true, ShippingTaxClass: "shipping"},
+			// 1999*825/10825 = 152.34, 1000*500/10500 = 47.62, 50*825/10825 = 3.81
+			[]service.LineTax{{Tax: usd(152), RateBPS: 825}, {Tax: usd(48), RateBPS: 500}, {Tax: usd(4), RateBPS: 825}},
+			200, // 1200*2000/12000
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tt.table.Calculate(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Inclusive != tt.table.Inclusive {
+				t.Errorf("Inclusive = %v, want %v", res.Inclusive, tt.table.Inclusive)
+			}
+			if len(res.Lines) != len(tt.lines) {
+				t.Fatalf("got %d lines, want %d", len(res.Lines), len(tt.lines))
+			}
+			for i, want := range tt.lines {
+				if res.Lines[i] != want {
+					t.Errorf("line %d = %+v, want %+v", i, res.Lines[i], want)
+				}
+			}
+			if want := usd(tt.shipping); res.Shipping != want {
+				t.Errorf("shipping tax = %v, want %v", res.Shipping, want)
+			}
+		})
+	}
+}
+
+func TestFlat(t *testing.T) {
+	res, err := Flat(1000).Calculate(context.Background(), service.TaxRequest{
+		Location: domain.TaxLocation{Country: "DE", PostalCode: "10115"},
+		Lines:    []service.TaxLine{{TaxClass: "anything", Amount: usd(1234)}},
+		Shipping: usd(500),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Lines[0].Tax != usd(123) || res.Shipping != usd(0) {
+		t.Errorf("Flat(1000) = %+v, shipping %v; want 1.23 USD and untaxed shipping", res.Lines[0], res.Shipping)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	write := func(t *testing.T, body string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "tax.json")
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	table, err := Load(write(t, `{"inclusive": true, "rules": [{"country": "us", "state": "ca", "postal_prefix": "900 1", "rate_bps": 950}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Rule{Country: "US", State: "CA", PostalPrefix: "9001", RateBPS: 950}); !table.Inclusive || table.Rules[0] != want {
+		t.Errorf("Load() = %+v, want an inclusive table with %+v", table, want)
+	}
+	if _, err := Load(write(t, `{"rules": [{"rate_bps": -1}]}`)); err == nil {
+		t.Error("negative rate loaded without an error")
+	}
+	if _, err := Load(write(t, `{"rules": `)); err == nil {
+		t.Error("malformed file loaded without an error")
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("missing file loaded without an error")
+	}
+}